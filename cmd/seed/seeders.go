@@ -420,11 +420,11 @@ func seedApplications(db *gorm.DB, users []user.User, projects []project.Project
 		if status == "OFFERED" {
 			expiryDays := rand.Intn(7) + 7 // 7-14 days
 			expiryDate := time.Now().AddDate(0, 0, expiryDays)
-			expiryDateVal := datatypes.Date(expiryDate)
-			app.OfferExpiresAt = &expiryDateVal
+			app.OfferExpiresAt = &expiryDate
 		}
 
 		db.Create(&app)
+		application.SyncMembers(db, &app)
 
 		// Update score with application ID if score exists
 		if score != nil {
@@ -446,23 +446,25 @@ func seedApplications(db *gorm.DB, users []user.User, projects []project.Project
 func seedMilestones(db *gorm.DB, projects []project.Project) []milestone.Milestone {
 	var milestones []milestone.Milestone
 
-	statuses := []string{"PROPOSED", "IN_PROGRESS", "SUBMITTED", "APPROVED", "RELEASED", "COMPLETED"}
+	statuses := []string{"PROPOSED", "ACCEPTED", "IN_PROGRESS", "SUBMITTED", "CHANGES_REQUESTED", "APPROVED", "RELEASED"}
 
 	for _, proj := range projects {
 		// Create 2-5 milestones per project
 		numMilestones := rand.Intn(4) + 2
 		for i := 0; i < numMilestones; i++ {
-			dueDate := time.Now().AddDate(0, 0, rand.Intn(90)+30).Format("2006-01-02")
-			amount := rand.Intn(10000) + 500
+			dueDate := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, rand.Intn(90)+30)
+			// Milestones share the project budget without exceeding it
+			share := int(proj.Budget.Value) / numMilestones
+			amount := share/2 + rand.Intn(share/2+1)
 
 			mil := milestone.Milestone{
 				ProjectID:          proj.ID,
 				Title:              fmt.Sprintf("Milestone %d: %s", i+1, getRandomMilestoneTitle()),
 				Scope:              fmt.Sprintf("Complete %s functionality for the project", getRandomFeature()),
 				AcceptanceCriteria: fmt.Sprintf("All tests passing, code reviewed, documentation updated"),
-				DueDate:            dueDate,
+				DueDate:            &dueDate,
 				Amount:             amount,
-				Currency:           "USD",
+				Currency:           proj.Budget.Currency,
 				Status:             statuses[rand.Intn(len(statuses))],
 			}
 			db.Create(&mil)
@@ -480,7 +482,7 @@ func seedPortfolio(db *gorm.DB, users []user.User, projects []project.Project, m
 	// Get completed milestones
 	completedMilestones := []milestone.Milestone{}
 	for _, mil := range milestones {
-		if mil.Status == "COMPLETED" || mil.Status == "RELEASED" {
+		if mil.Status == "APPROVED" || mil.Status == "RELEASED" {
 			completedMilestones = append(completedMilestones, mil)
 		}
 	}
@@ -514,8 +516,7 @@ func seedPortfolio(db *gorm.DB, users []user.User, projects []project.Project, m
 					}
 
 					// Check if on time
-					dueDate, _ := time.Parse("2006-01-02", mil.DueDate)
-					onTime := time.Now().Before(dueDate.AddDate(0, 0, 7)) // Within 7 days of due date
+					onTime := time.Now().Before(mil.DueDate.AddDate(0, 0, 7)) // Within 7 days of due date
 
 					portfolioItem := portfolio.PortfolioItem{
 						UserID:          usr.ID,
@@ -529,6 +530,7 @@ func seedPortfolio(db *gorm.DB, users []user.User, projects []project.Project, m
 						AmountDelivered: float64(mil.Amount),
 						Currency:        mil.Currency,
 						OnTime:          onTime,
+						Verified:        true,
 						VerifiedAt:      datatypes.Date(time.Now()),
 					}
 					db.Create(&portfolioItem)
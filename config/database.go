@@ -13,12 +13,16 @@ import (
 	delegatedaccess "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/DelegatedAccess"
 	department "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Department"
 	dispute "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Dispute"
+	exchangerate "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/ExchangeRate"
 	invitation "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Invitation"
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
 	milestone "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Milestone"
 	notification "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Notification"
 	organization "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Organization"
+	payment "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Payment"
 	portfolio "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Portfolio"
 	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	review "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Review"
 	student "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Student"
 	supervisor "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Supervisor"
 	supervisorrequest "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/SupervisorRequest"
@@ -43,12 +47,25 @@ func ConnectToDB() (*gorm.DB, error) {
 		return nil, err
 	}
 
-	migrationErr := db.AutoMigrate(&user.User{}, &organization.Organization{}, &branch.Branch{}, &college.College{}, &course.Course{}, &department.Department{}, &project.Project{}, &milestone.Milestone{}, &application.Application{}, &chat.Message{}, &dispute.Dispute{}, &invitation.Invitation{}, &notification.Notification{}, &student.Student{}, &supervisor.Supervisor{}, &supervisorrequest.SupervisorRequest{}, &portfolio.PortfolioItem{}, &auth.PasswordResetToken{}, &delegatedaccess.DelegatedAccess{})
+	// Milestone due dates used to be stored as text
+	if err := milestone.MigrateDueDates(db); err != nil {
+		fmt.Println("Failed to convert milestone due dates:", err)
+	}
+
+	migrationErr := db.AutoMigrate(&user.User{}, &organization.Organization{}, &branch.Branch{}, &college.College{}, &course.Course{}, &department.Department{}, &project.Project{}, &milestone.Milestone{}, &milestone.MilestoneEvent{}, &milestone.MilestoneSubmission{}, &milestone.SubmissionComment{}, &milestone.MilestoneTemplate{}, &milestone.MilestoneTemplateItem{}, &application.Application{}, &application.ApplicationMember{}, &application.ApplicationStatusEvent{}, &application.ApplicationRevision{}, &application.ScoringWeights{}, &ledger.LedgerTransaction{}, &ledger.LedgerEntry{}, &payment.Payment{}, &payment.PaymentWebhookEvent{}, &exchangerate.ExchangeRate{}, &chat.Message{}, &chat.MessageEdit{}, &chat.Attachment{}, &chat.ReadCursor{}, &dispute.Dispute{}, &invitation.Invitation{}, &notification.Notification{}, &student.Student{}, &supervisor.Supervisor{}, &supervisorrequest.SupervisorRequest{}, &portfolio.PortfolioItem{}, &review.Review{}, &auth.PasswordResetToken{}, &delegatedaccess.DelegatedAccess{})
 
 	if migrationErr != nil {
 		fmt.Println("Small migration issue: [DB HAS DATA]")
 	}
 
+	// Screening questions saved before preferred options were stored separately still carry them
+	if err := project.MigrateScreeningPreferences(db); err != nil {
+		fmt.Println("Failed to move preferred screening options:", err)
+	}
+
+	// Applications created before the application_members table need their member rows
+	application.BackfillMembers(db)
+
 	fmt.Println("Connected to DB successfully")
 
 	return db, nil
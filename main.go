@@ -15,12 +15,16 @@ import (
 	course "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Course"
 	delegatedaccess "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/DelegatedAccess"
 	department "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Department"
+	exchangerate "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/ExchangeRate"
 	invitation "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Invitation"
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
 	milestone "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Milestone"
 	notification "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Notification"
 	organization "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Organization"
+	payment "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Payment"
 	portfolio "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Portfolio"
 	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	review "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Review"
 	student "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Student"
 	supervisor "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Supervisor"
 	supervisorrequest "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/SupervisorRequest"
@@ -44,6 +48,9 @@ func main() {
 		log.Println("Note: .env file not found. Using environment variables from system (production mode)")
 	}
 
+	// Opt-in providers read their settings from the environment, so register them once it is loaded
+	payment.RegisterFakeProvider()
+
 	log.Println("Connecting to database...")
 	DB, DBError := config.ConnectToDB()
 
@@ -76,9 +83,26 @@ func main() {
 	analytics.RegisterRoutes(apiV1, DB)
 	supervisorrequest.RegisterRoutes(apiV1, DB)
 	portfolio.RegisterRoutes(apiV1, DB)
+	ledger.RegisterRoutes(apiV1, DB)
+	payment.RegisterRoutes(apiV1, DB)
+	exchangerate.RegisterRoutes(apiV1, DB)
+	review.RegisterRoutes(apiV1, DB)
 
 	log.Println("All routes registered successfully")
 
+	// Completed projects are recorded in the assigned students' portfolios
+	project.OnCompleted(portfolio.RecordProjectCompletion)
+
+	// Notifications and group membership changes are pushed over the chat websocket
+	notification.OnCreated(chat.PushNotification)
+	user.OnMembershipChanged(chat.PushMembershipChange)
+
+	// Background jobs
+	application.StartOfferSweeper(DB)
+	project.StartDeadlineScheduler(DB)
+	milestone.StartOverdueScheduler(DB)
+	chat.StartAttachmentSweeper(DB)
+
 	// Get port from environment (Railway uses PORT, local dev uses APP_PORT)
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -6,8 +6,11 @@ import (
 	"time"
 
 	application "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Application"
+	exchangerate "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/ExchangeRate"
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
 	portfolio "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Portfolio"
 	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	review "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Review"
 	student "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Student"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -23,16 +26,27 @@ type StudentAnalyticsResponse struct {
 	TotalEarnings        float64 `json:"totalEarnings"`
 	ActiveProjectsChange float64 `json:"activeProjectsChange,omitempty"`
 	TotalBudgetChange    float64 `json:"totalBudgetChange,omitempty"`
+	// Averages of the partner reviews the student has received
+	Rating review.Summary `json:"rating"`
+	// Amounts are reported in Currency; amounts in UnconvertedCurrencies had no exchange rate and are left out
+	Currency              string   `json:"currency"`
+	UnconvertedCurrencies []string `json:"unconvertedCurrencies,omitempty"`
 }
 
 // GetStudentAnalytics calculates analytics for the authenticated student
 func GetStudentAnalytics(c *fiber.Ctx, db *gorm.DB) error {
 	userID := c.Locals("user_id").(uint)
+	currency := exchangerate.ReportingCurrency(c.Query("currency"))
+	conv, err := exchangerate.NewConverter(db)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load exchange rates: " + err.Error()})
+	}
+	now := time.Now()
 
 	// Get all applications for this student
 	var applications []application.Application
-	// Use PostgreSQL JSONB contains operator to find applications where student is in student_ids
-	if err := db.Where("CAST(student_ids AS jsonb) @> ?::jsonb", fmt.Sprintf(`[%d]`, userID)).
+	// Look up the student's applications through their application_members rows
+	if err := db.Where("id IN (?)", db.Model(&application.ApplicationMember{}).Select("application_id").Where("user_id = ?", userID)).
 		Preload("Project").
 		Find(&applications).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get applications: " + err.Error()})
@@ -60,7 +74,6 @@ func GetStudentAnalytics(c *fiber.Ctx, db *gorm.DB) error {
 	activeProjects := 0
 	completedProjects := 0
 	totalBudget := 0.0
-	totalEarnings := 0.0
 
 	// Count applications by status
 	for _, app := range applications {
@@ -71,24 +84,32 @@ func GetStudentAnalytics(c *fiber.Ctx, db *gorm.DB) error {
 
 	// Calculate project statistics
 	for _, proj := range projects {
-		// Calculate budget (convert to float64)
-		if proj.Budget.Currency != "" {
-			totalBudget += float64(proj.Budget.Value)
-		}
+		// Calculate budget in the reporting currency
+		totalBudget += conv.Sum(float64(proj.Budget.Value), proj.Budget.Currency, currency, now)
 
 		// Count by status
 		if proj.Status == "in-progress" {
 			activeProjects++
 		} else if proj.Status == "completed" {
 			completedProjects++
-			// For completed projects, calculate earnings (assume full budget for now)
-			// In production, this would come from actual payout/milestone data
-			if proj.Budget.Currency != "" {
-				totalEarnings += float64(proj.Budget.Value)
-			}
 		}
 	}
 
+	// Earnings are what milestone releases have actually paid the student
+	earnings, err := ledger.Earnings(db, userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get earnings: " + err.Error()})
+	}
+	totalEarnings := 0.0
+	for code, amount := range earnings {
+		totalEarnings += conv.Sum(float64(amount), code, currency, now)
+	}
+
+	rating, err := review.SummaryFor(db, []uint{userID}, review.DirectionPartnerToStudent)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get reviews: " + err.Error()})
+	}
+
 	// Calculate changes (simplified - in production would compare with historical data)
 	activeProjectsChange := 0.0
 	if activeProjects > 0 {
@@ -101,14 +122,17 @@ func GetStudentAnalytics(c *fiber.Ctx, db *gorm.DB) error {
 	}
 
 	response := StudentAnalyticsResponse{
-		TotalApplications:    totalApplications,
-		ActiveApplications:   activeApplications,
-		CompletedProjects:    completedProjects,
-		ActiveProjects:       activeProjects,
-		TotalBudget:          totalBudget,
-		TotalEarnings:        totalEarnings,
-		ActiveProjectsChange: activeProjectsChange,
-		TotalBudgetChange:    totalBudgetChange,
+		TotalApplications:     totalApplications,
+		ActiveApplications:    activeApplications,
+		CompletedProjects:     completedProjects,
+		ActiveProjects:        activeProjects,
+		TotalBudget:           totalBudget,
+		TotalEarnings:         totalEarnings,
+		ActiveProjectsChange:  activeProjectsChange,
+		TotalBudgetChange:     totalBudgetChange,
+		Rating:                rating,
+		Currency:              currency,
+		UnconvertedCurrencies: conv.Unconverted(),
 	}
 
 	return c.JSON(fiber.Map{"data": response})
@@ -129,6 +153,13 @@ type UniversityAdminAnalyticsResponse struct {
 	GenderDistribution    []GenderDistributionPoint  `json:"genderDistribution"`
 	BranchDistribution    []BranchDistributionPoint  `json:"branchDistribution"`
 	DistrictDistribution  []DistrictDistributionPoint `json:"districtDistribution"`
+
+	// Averages of the partner reviews the university's students have received
+	StudentRating review.Summary `json:"studentRating"`
+
+	// Amounts are reported in Currency; amounts in UnconvertedCurrencies had no exchange rate and are left out
+	Currency              string   `json:"currency"`
+	UnconvertedCurrencies []string `json:"unconvertedCurrencies,omitempty"`
 }
 
 type RevenueTrendPoint struct {
@@ -164,6 +195,12 @@ func GetUniversityAdminAnalytics(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"msg": "invalid organization id"})
 	}
 
+	currency := exchangerate.ReportingCurrency(c.Query("currency"))
+	conv, err := exchangerate.NewConverter(db)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load exchange rates: " + err.Error()})
+	}
+
 	// Get all students for this university
 	var students []student.Student
 	if err := db.Table("students").
@@ -182,10 +219,10 @@ func GetUniversityAdminAnalytics(c *fiber.Ctx, db *gorm.DB) error {
 		studentUserIDs = append(studentUserIDs, s.UserID)
 	}
 
-	// Get all portfolio items for these students (for earnings calculation)
+	// Get all verified portfolio items for these students (for earnings calculation)
 	var portfolioItems []portfolio.PortfolioItem
 	if len(studentUserIDs) > 0 {
-		if err := db.Where("user_id IN ?", studentUserIDs).
+		if err := db.Where("user_id IN ? AND verified = ?", studentUserIDs, true).
 			Preload("Project").
 			Find(&portfolioItems).Error; err != nil {
 			return c.Status(400).JSON(fiber.Map{"msg": "failed to get portfolio items: " + err.Error()})
@@ -201,11 +238,11 @@ func GetUniversityAdminAnalytics(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get projects: " + err.Error()})
 	}
 
-	// Calculate total revenue from completed projects
+	// Calculate total revenue from completed projects, at the rate in effect when each completed
 	totalRevenue := 0.0
 	for _, proj := range projects {
-		if proj.Status == "completed" && proj.Budget.Currency != "" {
-			totalRevenue += float64(proj.Budget.Value)
+		if proj.Status == "completed" {
+			totalRevenue += conv.Sum(float64(proj.Budget.Value), proj.Budget.Currency, currency, proj.UpdatedAt)
 		}
 	}
 
@@ -213,15 +250,15 @@ func GetUniversityAdminAnalytics(c *fiber.Ctx, db *gorm.DB) error {
 	totalStudentEarnings := 0.0
 	studentsWithEarnings := make(map[uint]bool)
 	for _, item := range portfolioItems {
-		totalStudentEarnings += item.AmountDelivered
+		totalStudentEarnings += conv.Sum(item.AmountDelivered, item.Currency, currency, earnedAt(item))
 		studentsWithEarnings[item.UserID] = true
 	}
 
 	// Calculate revenue trend (last 12 months)
-	revenueTrend := calculateRevenueTrend(projects)
+	revenueTrend := calculateRevenueTrend(projects, conv, currency)
 
 	// Calculate earnings trend (last 12 months)
-	earningsTrend := calculateEarningsTrend(portfolioItems)
+	earningsTrend := calculateEarningsTrend(portfolioItems, conv, currency)
 
 	// Calculate gender distribution
 	genderDist := calculateGenderDistribution(students)
@@ -232,22 +269,31 @@ func GetUniversityAdminAnalytics(c *fiber.Ctx, db *gorm.DB) error {
 	// Calculate district distribution
 	districtDist := calculateDistrictDistribution(students)
 
+	// Average the partner reviews of the university's students
+	studentRating, err := review.SummaryFor(db, studentUserIDs, review.DirectionPartnerToStudent)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get reviews: " + err.Error()})
+	}
+
 	response := UniversityAdminAnalyticsResponse{
-		TotalRevenue:         totalRevenue,
-		RevenueTrend:         revenueTrend,
-		TotalStudentEarnings: totalStudentEarnings,
-		EarningsTrend:        earningsTrend,
-		StudentsEarningCount: len(studentsWithEarnings),
-		GenderDistribution:   genderDist,
-		BranchDistribution:   branchDist,
-		DistrictDistribution: districtDist,
+		TotalRevenue:          totalRevenue,
+		RevenueTrend:          revenueTrend,
+		TotalStudentEarnings:  totalStudentEarnings,
+		EarningsTrend:         earningsTrend,
+		StudentsEarningCount:  len(studentsWithEarnings),
+		GenderDistribution:    genderDist,
+		BranchDistribution:    branchDist,
+		DistrictDistribution:  districtDist,
+		StudentRating:         studentRating,
+		Currency:              currency,
+		UnconvertedCurrencies: conv.Unconverted(),
 	}
 
 	return c.JSON(fiber.Map{"data": response})
 }
 
-// calculateRevenueTrend calculates revenue over the last 12 months
-func calculateRevenueTrend(projects []project.Project) []RevenueTrendPoint {
+// calculateRevenueTrend calculates revenue over the last 12 months in the reporting currency
+func calculateRevenueTrend(projects []project.Project, conv *exchangerate.Converter, currency string) []RevenueTrendPoint {
 	now := time.Now()
 	trend := make([]RevenueTrendPoint, 12)
 	monthNames := []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
@@ -259,10 +305,10 @@ func calculateRevenueTrend(projects []project.Project) []RevenueTrendPoint {
 
 		revenue := 0.0
 		for _, proj := range projects {
-			if proj.Status == "completed" && proj.Budget.Currency != "" {
+			if proj.Status == "completed" {
 				completedAt := proj.UpdatedAt // Use UpdatedAt as completion time
 				if completedAt.After(monthStart) && completedAt.Before(monthEnd) {
-					revenue += float64(proj.Budget.Value)
+					revenue += conv.Sum(float64(proj.Budget.Value), proj.Budget.Currency, currency, completedAt)
 				}
 			}
 		}
@@ -276,8 +322,17 @@ func calculateRevenueTrend(projects []project.Project) []RevenueTrendPoint {
 	return trend
 }
 
-// calculateEarningsTrend calculates student earnings over the last 12 months
-func calculateEarningsTrend(portfolioItems []portfolio.PortfolioItem) []EarningsTrendPoint {
+// earnedAt is when a portfolio item's amount was earned: its verification date, or its creation
+// time if it has none
+func earnedAt(item portfolio.PortfolioItem) time.Time {
+	if verifiedAt := time.Time(item.VerifiedAt); !verifiedAt.IsZero() {
+		return verifiedAt
+	}
+	return item.CreatedAt
+}
+
+// calculateEarningsTrend calculates student earnings over the last 12 months in the reporting currency
+func calculateEarningsTrend(portfolioItems []portfolio.PortfolioItem, conv *exchangerate.Converter, currency string) []EarningsTrendPoint {
 	now := time.Now()
 	trend := make([]EarningsTrendPoint, 12)
 	monthNames := []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
@@ -295,7 +350,7 @@ func calculateEarningsTrend(portfolioItems []portfolio.PortfolioItem) []Earnings
 				continue
 			}
 			if verifiedAt.After(monthStart) && verifiedAt.Before(monthEnd) {
-				earnings += item.AmountDelivered
+				earnings += conv.Sum(item.AmountDelivered, item.Currency, currency, verifiedAt)
 			}
 		}
 
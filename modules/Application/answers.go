@@ -0,0 +1,130 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"gorm.io/datatypes"
+)
+
+// applicationUploadDir is where UploadFiles stores application files
+const applicationUploadDir = "uploads/applications"
+
+// ScreeningAnswer is a student's answer to one of the project's screening questions.
+// Type and Prompt are copied from the question so answers stay readable if the project changes.
+type ScreeningAnswer struct {
+	QuestionID string `json:"questionId"`
+	Type       string `json:"type"`
+	Prompt     string `json:"prompt"`
+	Value      string `json:"value"`
+}
+
+// isUploadedFile reports whether path points at a file saved by UploadFiles
+func isUploadedFile(path string) bool {
+	clean := filepath.Clean(path)
+	if filepath.Dir(clean) != filepath.Clean(applicationUploadDir) {
+		return false
+	}
+	info, err := os.Stat(clean)
+	return err == nil && !info.IsDir()
+}
+
+// validateAnswers checks the submitted answers against the project's screening questions and
+// returns them in question order, ready to store
+func validateAnswers(questions []project.ScreeningQuestion, answers []ScreeningAnswer) (datatypes.JSON, error) {
+	if len(questions) == 0 && len(answers) == 0 {
+		return nil, nil
+	}
+
+	byQuestion := make(map[string]string, len(answers))
+	for _, answer := range answers {
+		if _, dup := byQuestion[answer.QuestionID]; dup {
+			return nil, fmt.Errorf("question %s is answered more than once", answer.QuestionID)
+		}
+		byQuestion[answer.QuestionID] = strings.TrimSpace(answer.Value)
+	}
+
+	validated := make([]ScreeningAnswer, 0, len(questions))
+	for _, q := range questions {
+		value, answered := byQuestion[q.ID]
+		delete(byQuestion, q.ID)
+
+		if !answered || value == "" {
+			if q.Required {
+				return nil, fmt.Errorf("question %q is required", q.Prompt)
+			}
+			continue
+		}
+
+		switch q.Type {
+		case project.QuestionShortText:
+			if utf8.RuneCountInString(value) > q.AnswerMaxLength() {
+				return nil, fmt.Errorf("answer to %q must be at most %d characters", q.Prompt, q.AnswerMaxLength())
+			}
+		case project.QuestionMultipleChoice:
+			if !q.HasOption(value) {
+				return nil, fmt.Errorf("answer to %q must be one of its options", q.Prompt)
+			}
+		case project.QuestionFile:
+			if !isUploadedFile(value) {
+				return nil, fmt.Errorf("answer to %q must be a file uploaded through /applications/upload", q.Prompt)
+			}
+		}
+
+		validated = append(validated, ScreeningAnswer{
+			QuestionID: q.ID,
+			Type:       q.Type,
+			Prompt:     q.Prompt,
+			Value:      value,
+		})
+	}
+
+	for questionID := range byQuestion {
+		return nil, fmt.Errorf("question %s does not exist on this project", questionID)
+	}
+
+	answersJSON, err := json.Marshal(validated)
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.JSON(answersJSON), nil
+}
+
+// parseAnswers decodes the stored answers of an application
+func parseAnswers(data datatypes.JSON) []ScreeningAnswer {
+	var answers []ScreeningAnswer
+	if len(data) > 0 {
+		json.Unmarshal(data, &answers)
+	}
+	return answers
+}
+
+// computeScreeningScore returns the share of the project's scored questions answered with a
+// preferred option. ok is false when the project has no questions with preferred options.
+func computeScreeningScore(questions []project.ScreeningQuestion, answers []ScreeningAnswer) (score float64, ok bool) {
+	given := make(map[string]string, len(answers))
+	for _, answer := range answers {
+		given[answer.QuestionID] = answer.Value
+	}
+
+	scored, matched := 0, 0
+	for _, q := range questions {
+		if q.Type != project.QuestionMultipleChoice || len(q.PreferredOptions) == 0 {
+			continue
+		}
+		scored++
+		if q.IsPreferred(given[q.ID]) {
+			matched++
+		}
+	}
+
+	if scored == 0 {
+		return 0, false
+	}
+	return float64(matched) / float64(scored) * 100, true
+}
@@ -0,0 +1,139 @@
+package application
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxBulkApplications caps how many applications a single bulk action may touch
+const maxBulkApplications = 500
+
+// bulkTargetStatuses are the statuses a bulk action may move applications to. Offers go through
+// the offer endpoint so capacity is checked per team.
+var bulkTargetStatuses = map[string]bool{
+	StatusShortlisted: true,
+	StatusWaitlist:    true,
+	StatusRejected:    true,
+}
+
+// BulkActionResult reports the outcome of a bulk action for one application
+type BulkActionResult struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Status  string `json:"status,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// statusNotification builds the notification sent to students when their application changes status.
+// message, when set, is the reviewer's note and is appended to the template.
+func statusNotification(application Application, newStatus, message string) (string, string) {
+	var title, body string
+	switch newStatus {
+	case StatusShortlisted:
+		title = "Application Shortlisted"
+		body = fmt.Sprintf("Good news! Your application for \"%s\" has been shortlisted.", application.Project.Title)
+	case StatusWaitlist:
+		title = "Application Waitlisted"
+		body = fmt.Sprintf("Your application for \"%s\" has been placed on the waitlist. We will let you know if a place opens up.", application.Project.Title)
+	case StatusRejected:
+		title = "Application Update"
+		body = fmt.Sprintf("Thank you for applying to \"%s\". Unfortunately your application was not successful this time.", application.Project.Title)
+	default:
+		title = "Application Update"
+		body = fmt.Sprintf("Your application for \"%s\" is now %s.", application.Project.Title, newStatus)
+	}
+	if message != "" {
+		body += "\n\n" + message
+	}
+	return title, body
+}
+
+// BulkAction moves several applications to the same status in one transaction.
+// Applications the caller cannot manage, or that cannot make the move, are reported and skipped.
+func BulkAction(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	type BulkActionRequest struct {
+		IDs     []uint `json:"ids"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+
+	var req BulkActionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid request data: " + err.Error()})
+	}
+
+	if len(req.IDs) == 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "ids are required"})
+	}
+	if len(req.IDs) > maxBulkApplications {
+		return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("a bulk action can update at most %d applications", maxBulkApplications)})
+	}
+	if !bulkTargetStatuses[req.Status] {
+		return c.Status(400).JSON(fiber.Map{"msg": "status must be one of SHORTLISTED, WAITLIST or REJECTED"})
+	}
+
+	results := make([]BulkActionResult, 0, len(req.IDs))
+	var updated []Application
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		var applications []Application
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}}).
+			Preload("Project").
+			Where("id IN ?", req.IDs).
+			Find(&applications).Error; err != nil {
+			return err
+		}
+
+		byID := make(map[uint]*Application, len(applications))
+		for i := range applications {
+			byID[applications[i].ID] = &applications[i]
+		}
+
+		seen := make(map[uint]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			application, ok := byID[id]
+			if !ok {
+				results = append(results, BulkActionResult{ID: id, Error: "application not found"})
+				continue
+			}
+			if !canManageApplication(tx, *application, userID, role) {
+				results = append(results, BulkActionResult{ID: id, Status: application.Status, Error: "you don't have permission to update this application status"})
+				continue
+			}
+			if !CanTransition(application.Status, req.Status, role) {
+				results = append(results, BulkActionResult{ID: id, Status: application.Status, Error: transitionError(application.Status, req.Status, role).Error()})
+				continue
+			}
+
+			if err := changeStatus(tx, application, req.Status, userID, role, req.Message); err != nil {
+				return err
+			}
+			results = append(results, BulkActionResult{ID: id, Success: true, Status: application.Status})
+			updated = append(updated, *application)
+		}
+		return nil
+	}); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to apply bulk action: " + err.Error()})
+	}
+
+	for _, application := range updated {
+		title, message := statusNotification(application, req.Status, req.Message)
+		notifyApplicants(db, application, "application_status", title, message, "/applications")
+	}
+
+	return c.JSON(fiber.Map{
+		"msg":  fmt.Sprintf("%d of %d applications %s", len(updated), len(results), req.Status),
+		"data": results,
+	})
+}
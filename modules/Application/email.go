@@ -0,0 +1,88 @@
+package application
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strings"
+
+	core "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Core"
+	mailer "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Mailer"
+	"github.com/mailjet/mailjet-apiv3-go/v4"
+)
+
+// SendApplicationEmail sends an application update email to a student.
+// link is a frontend path such as "/applications".
+func SendApplicationEmail(email, name, subject, message, link string) error {
+	mailjetKey := os.Getenv("MAILJET_KEY")
+	mailjetSecret := os.Getenv("MAILJET_SECRET")
+	mailjetEmail := os.Getenv("MAILJET_EMAIL")
+	mailjetFrom := os.Getenv("MAILJET_FROM")
+
+	if mailjetKey == "" || mailjetSecret == "" || mailjetEmail == "" {
+		return fmt.Errorf("mailjet configuration missing")
+	}
+
+	if mailjetFrom == "" {
+		mailjetFrom = "StrikeForce"
+	}
+
+	// Get frontend URL from centralized config
+	actionURL := core.GetFrontendURL() + link
+
+	htmlPart := applicationEmailHTML(subject, name, message, actionURL)
+
+	textPart := fmt.Sprintf(
+		"Hello %s,\n\n%s\n\nView on StrikeForce: %s\n\nBest regards,\nThe StrikeForce Team",
+		name,
+		message,
+		actionURL,
+	)
+
+	mj := mailjet.NewMailjetClient(mailjetKey, mailjetSecret)
+	msg := mailjet.InfoMessagesV31{
+		From: &mailjet.RecipientV31{
+			Email: mailjetEmail,
+			Name:  mailjetFrom,
+		},
+		To: &mailjet.RecipientsV31{
+			{
+				Email: email,
+				Name:  name,
+			},
+		},
+		Subject:  subject,
+		TextPart: textPart,
+		HTMLPart: htmlPart,
+	}
+
+	messages := mailjet.MessagesV31{
+		Info: []mailjet.InfoMessagesV31{msg},
+	}
+
+	_, err := mj.SendMailV31(&messages)
+	return mailer.InterpretMailjetError(err, "application email")
+}
+
+// applicationEmailHTML builds the HTML body of an application email. Every value is escaped: the subject
+// carries project titles and the message can be a partner's free-form text. Line breaks in the message
+// are kept.
+func applicationEmailHTML(subject, name, message, actionURL string) string {
+	return fmt.Sprintf(
+		`<div style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
+			<h2>%s</h2>
+			<p>Hello %s,</p>
+			<p>%s</p>
+			<p style="margin: 30px 0;">
+				<a href="%s" style="background-color: #e9226e; color: white; padding: 12px 24px; text-decoration: none; border-radius: 4px; display: inline-block;">
+					View on StrikeForce
+				</a>
+			</p>
+			<p>Best regards,<br>The StrikeForce Team</p>
+		</div>`,
+		html.EscapeString(subject),
+		html.EscapeString(name),
+		strings.ReplaceAll(html.EscapeString(message), "\n", "<br>"),
+		html.EscapeString(actionURL),
+	)
+}
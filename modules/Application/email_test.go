@@ -0,0 +1,26 @@
+package application
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplicationEmailHTMLEscapesValues(t *testing.T) {
+	body := applicationEmailHTML(
+		`Update on <b>"Website" & app</b>`,
+		"Jane <Doe>",
+		"Great work!\n\n<a href=\"https://evil.example\">Claim your prize</a><script>alert(1)</script>",
+		`https://app.example/applications?x="><img>`,
+	)
+
+	for _, unsafe := range []string{"<b>", "<Doe>", "<a href=\"https://evil.example\"", "<script>", `"><img>`} {
+		if strings.Contains(body, unsafe) {
+			t.Errorf("email body contains unescaped %q", unsafe)
+		}
+	}
+	for _, want := range []string{"&lt;b&gt;", "&amp; app", "Jane &lt;Doe&gt;", "&lt;script&gt;", "Great work!<br><br>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("email body does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultMaxActiveApplications is used when MAX_ACTIVE_APPLICATIONS is unset or invalid
+const defaultMaxActiveApplications = 5
+
+// activeStatuses are the statuses of applications that are still being considered.
+// They count towards a student's limit on simultaneous applications.
+var activeStatuses = []string{StatusSubmitted, StatusShortlisted, StatusWaitlist, StatusOffered, StatusAccepted}
+
+// closedStatuses are the statuses that free a student to apply to the same project again
+var closedStatuses = []string{StatusRejected, StatusDeclined, StatusExpired, StatusWithdrawn}
+
+// maxActiveApplications returns how many active applications a student may have at once
+func maxActiveApplications() int64 {
+	limit, err := strconv.Atoi(os.Getenv("MAX_ACTIVE_APPLICATIONS"))
+	if err != nil || limit <= 0 {
+		limit = defaultMaxActiveApplications
+	}
+	return int64(limit)
+}
+
+// memberApplicationIDs returns a subquery selecting the IDs of the applications a user belongs to
+func memberApplicationIDs(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&ApplicationMember{}).Select("application_id").Where("user_id = ?", userID)
+}
+
+// isApplicationMember reports whether a user is one of the students on an application
+func isApplicationMember(db *gorm.DB, applicationID, userID uint) bool {
+	var count int64
+	if err := db.Model(&ApplicationMember{}).
+		Where("application_id = ? AND user_id = ?", applicationID, userID).
+		Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// applicationConflict is the error returned when an application breaks the duplicate or limit rules,
+// as opposed to a failed query
+type applicationConflict struct {
+	msg string
+}
+
+func (e applicationConflict) Error() string {
+	return e.msg
+}
+
+// isApplicationConflict reports whether err is an applicationConflict
+func isApplicationConflict(err error) bool {
+	var conflict applicationConflict
+	return errors.As(err, &conflict)
+}
+
+// lockStudents locks the user rows of the given students until the transaction ends, so that concurrent
+// submissions involving the same student run their conflict checks one after the other
+func lockStudents(tx *gorm.DB, studentIDs []uint) error {
+	if len(studentIDs) == 0 {
+		return nil
+	}
+	var locked []uint
+	return tx.Table("users").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", studentIDs).
+		Order("id").
+		Pluck("id", &locked).Error
+}
+
+// checkApplicationConflicts rejects an application when one of its students already has an open
+// application on the same project or has reached the limit on simultaneous active applications.
+// excludeID skips the application being resubmitted; pass 0 for new applications. Run it inside the
+// transaction that writes the application, after lockStudents.
+func checkApplicationConflicts(db *gorm.DB, projectID uint, studentIDs []uint, excludeID uint) error {
+	seen := make(map[uint]bool, len(studentIDs))
+	for _, sid := range studentIDs {
+		if seen[sid] {
+			return applicationConflict{fmt.Sprintf("student %d is listed more than once on this application", sid)}
+		}
+		seen[sid] = true
+	}
+
+	var duplicates []uint
+	if err := db.Table("application_members").
+		Joins("JOIN applications ON applications.id = application_members.application_id").
+		Where("application_members.project_id = ? AND application_members.user_id IN ?", projectID, studentIDs).
+		Where("applications.deleted_at IS NULL AND applications.status NOT IN ?", closedStatuses).
+		Where("applications.id <> ?", excludeID).
+		Distinct().
+		Pluck("application_members.user_id", &duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return applicationConflict{fmt.Sprintf("%s already applied to this project", describeStudents(db, duplicates))}
+	}
+
+	limit := maxActiveApplications()
+	var atLimit []uint
+	if err := db.Table("application_members").
+		Joins("JOIN applications ON applications.id = application_members.application_id").
+		Where("application_members.user_id IN ?", studentIDs).
+		Where("applications.deleted_at IS NULL AND applications.status IN ?", activeStatuses).
+		Where("applications.id <> ?", excludeID).
+		Group("application_members.user_id").
+		Having("COUNT(*) >= ?", limit).
+		Pluck("application_members.user_id", &atLimit).Error; err != nil {
+		return err
+	}
+	if len(atLimit) > 0 {
+		return applicationConflict{fmt.Sprintf("%s already has %d active applications, which is the maximum", describeStudents(db, atLimit), limit)}
+	}
+
+	return nil
+}
+
+// describeStudents names the given students for error messages, falling back to their IDs
+func describeStudents(db *gorm.DB, userIDs []uint) string {
+	var names []string
+	db.Table("users").Where("id IN ?", userIDs).Order("id").Pluck("name", &names)
+	if len(names) != len(userIDs) {
+		return fmt.Sprintf("students %v", userIDs)
+	}
+	return strings.Join(names, ", ")
+}
+
+// SyncMembers replaces the member rows of an application with its current StudentIDs.
+// Call it whenever an application is created or its StudentIDs change.
+func SyncMembers(tx *gorm.DB, application *Application) error {
+	if err := tx.Where("application_id = ?", application.ID).Delete(&ApplicationMember{}).Error; err != nil {
+		return err
+	}
+
+	studentIDs := applicationStudentIDs(*application)
+	if len(studentIDs) == 0 {
+		return nil
+	}
+
+	members := make([]ApplicationMember, 0, len(studentIDs))
+	for _, sid := range studentIDs {
+		members = append(members, ApplicationMember{
+			ApplicationID: application.ID,
+			ProjectID:     application.ProjectID,
+			UserID:        sid,
+		})
+	}
+	return tx.Create(&members).Error
+}
+
+// BackfillMembers creates member rows for applications that predate the application_members table
+func BackfillMembers(db *gorm.DB) {
+	var applications []Application
+	if err := db.Where("id NOT IN (?)", db.Model(&ApplicationMember{}).Select("application_id")).
+		Find(&applications).Error; err != nil {
+		log.Printf("Warning: failed to find applications without members: %v", err)
+		return
+	}
+
+	for i := range applications {
+		if err := SyncMembers(db, &applications[i]); err != nil {
+			log.Printf("Warning: failed to backfill members of application %d: %v", applications[i].ID, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestIsApplicationConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"conflict", applicationConflict{"already applied"}, true},
+		{"wrapped conflict", fmt.Errorf("create: %w", applicationConflict{"already applied"}), true},
+		{"query failure", errors.New("connection reset"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isApplicationConflict(tt.err); got != tt.want {
+				t.Errorf("isApplicationConflict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckApplicationConflictsRejectsRepeatedStudents(t *testing.T) {
+	// Repeated students are caught before any query, so no database is needed
+	err := checkApplicationConflicts(nil, 1, []uint{4, 5, 4}, 0)
+	if !isApplicationConflict(err) {
+		t.Fatalf("checkApplicationConflicts() = %v, want a conflict", err)
+	}
+}
+
+// testTx opens DATABASE_URL and returns a transaction, rolled back when the test ends, in which temporary
+// users, applications and application_members tables shadow the real ones. Tests using it are skipped
+// when DATABASE_URL is unset.
+func testTx(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+
+	for _, stmt := range []string{
+		"CREATE TEMP TABLE users (id bigint PRIMARY KEY, name text) ON COMMIT DROP",
+		"CREATE TEMP TABLE applications (id bigint PRIMARY KEY, project_id bigint, status text, deleted_at timestamptz) ON COMMIT DROP",
+		"CREATE TEMP TABLE application_members (application_id bigint, project_id bigint, user_id bigint) ON COMMIT DROP",
+	} {
+		if err := tx.Exec(stmt).Error; err != nil {
+			t.Fatalf("failed to create test tables: %v", err)
+		}
+	}
+	return tx
+}
+
+func TestCheckApplicationConflicts(t *testing.T) {
+	tx := testTx(t)
+	t.Setenv("MAX_ACTIVE_APPLICATIONS", "2")
+
+	const ann, ben, cal, dee = 1, 2, 3, 4
+	for _, stmt := range []string{
+		"INSERT INTO users (id, name) VALUES (1, 'Ann'), (2, 'Ben'), (3, 'Cal'), (4, 'Dee')",
+		`INSERT INTO applications (id, project_id, status, deleted_at) VALUES
+			(1, 100, 'SUBMITTED', NULL),
+			(2, 100, 'WITHDRAWN', NULL),
+			(3, 100, 'SUBMITTED', now()),
+			(4, 200, 'SHORTLISTED', NULL),
+			(5, 201, 'OFFERED', NULL),
+			(6, 202, 'WAITLIST', NULL),
+			(7, 203, 'REJECTED', NULL),
+			(8, 204, 'ASSIGNED', NULL)`,
+		`INSERT INTO application_members (application_id, project_id, user_id) VALUES
+			(1, 100, 1), (2, 100, 2), (3, 100, 3), (4, 200, 3), (5, 201, 3), (6, 202, 4), (7, 203, 4), (8, 204, 4)`,
+	} {
+		if err := tx.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		projectID  uint
+		studentIDs []uint
+		excludeID  uint
+		want       string // Expected conflict message fragment; empty when the application is allowed
+	}{
+		{"open application on the project", 100, []uint{ann}, 0, "Ann already applied"},
+		{"resubmitting the open application", 100, []uint{ann}, 1, ""},
+		{"earlier application withdrawn", 100, []uint{ben}, 0, ""},
+		{"earlier application deleted but at the limit", 100, []uint{cal}, 0, "Cal already has 2 active applications"},
+		{"rejected and assigned applications are not active", 100, []uint{dee}, 0, ""},
+		{"group with a student who already applied", 100, []uint{dee, ann}, 0, "Ann already applied"},
+		{"resubmitting one of the applications at the limit", 200, []uint{cal}, 4, ""},
+		{"group with a student at the limit", 300, []uint{ben, cal}, 0, "Cal already has 2 active applications"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := lockStudents(tx, tt.studentIDs); err != nil {
+				t.Fatalf("lockStudents() error = %v", err)
+			}
+			err := checkApplicationConflicts(tx, tt.projectID, tt.studentIDs, tt.excludeID)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("checkApplicationConflicts() = %v, want no conflict", err)
+				}
+				return
+			}
+			if !isApplicationConflict(err) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("checkApplicationConflicts() = %v, want a conflict containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package application
 
 import (
+	"time"
+
 	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
 	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
 	"gorm.io/datatypes"
@@ -9,16 +11,77 @@ import (
 
 type Application struct {
 	gorm.Model
-	ProjectID      uint                  `json:"projectId"`
-	Project        project.Project       `json:"project" gorm:"foreignKey:ProjectID"`
-	ApplicantType  string                `json:"applicantType" gorm:"default:'INDIVIDUAL'"` // INDIVIDUAL or GROUP
-	StudentIDs     datatypes.JSON        `json:"studentIds" gorm:"type:json"`                // Array of user IDs
-	GroupID        *uint                 `json:"groupId"`
-	Group          *user.Group           `json:"group" gorm:"foreignKey:GroupID"`
-	Statement      string                `json:"statement"`
-	Status         string                `json:"status" gorm:"default:'SUBMITTED'"` // SUBMITTED, SHORTLISTED, WAITLIST, REJECTED, OFFERED, ACCEPTED, DECLINED, ASSIGNED
-	Attachments    datatypes.JSON        `json:"attachments" gorm:"type:json"`      // Array of file paths
-	PortfolioScore float64               `json:"portfolioScore" gorm:"default:0"`
-	Score          datatypes.JSON        `json:"score" gorm:"type:json"`           // Scoring data: autoScore, manualSupervisorScore, finalScore, skillMatch, portfolioScore, ratingScore, onTimeRate, reworkRate
-	OfferExpiresAt *datatypes.Date       `json:"offerExpiresAt"`
+	ProjectID      uint               `json:"projectId"`
+	Project        project.Project    `json:"project" gorm:"foreignKey:ProjectID"`
+	ApplicantType  string             `json:"applicantType" gorm:"default:'INDIVIDUAL'"` // INDIVIDUAL or GROUP
+	StudentIDs     datatypes.JSON     `json:"studentIds" gorm:"type:json"`               // Array of user IDs
+	GroupID        *uint              `json:"groupId"`
+	Group          *user.Group        `json:"group" gorm:"foreignKey:GroupID"`
+	Statement      string             `json:"statement"`
+	Status         string             `json:"status" gorm:"default:'SUBMITTED'"` // SUBMITTED, SHORTLISTED, WAITLIST, REJECTED, OFFERED, ACCEPTED, DECLINED, ASSIGNED, EXPIRED, WITHDRAWN
+	Attachments    datatypes.JSON     `json:"attachments" gorm:"type:json"`      // Array of file paths
+	PortfolioScore float64            `json:"portfolioScore" gorm:"default:0"`
+	Score          datatypes.JSON     `json:"score" gorm:"type:json"` // Scoring data: autoScore, manualSupervisorScore, finalScore, skillMatch, portfolioScore, ratingScore, onTimeRate, reworkRate, screeningScore
+	OfferExpiresAt *time.Time         `json:"offerExpiresAt"`
+	Answers        datatypes.JSON     `json:"answers" gorm:"type:json"`          // Array of ScreeningAnswer
+	Version        int                `json:"version" gorm:"default:1"`          // Bumped on every edit, see ApplicationRevision
+	Applicants     []ApplicantProfile `json:"applicants,omitempty" gorm:"-"`     // Populated on GET, not stored
+	CandidateLabel string             `json:"candidateLabel,omitempty" gorm:"-"` // Stable "Candidate N" handle within the project
+	Anonymized     bool               `json:"anonymized,omitempty" gorm:"-"`     // Set when identities are hidden by blind review
+}
+
+// ApplicantProfile is the public view of a student on an application
+type ApplicantProfile struct {
+	UserID   uint   `json:"userId,omitempty"`
+	Name     string `json:"name"`
+	Email    string `json:"email,omitempty"`
+	Gender   string `json:"gender,omitempty"`
+	District string `json:"district,omitempty"`
+	Avatar   string `json:"avatar,omitempty"`
+}
+
+// ApplicationStatusEvent records a single status change on an application
+type ApplicationStatusEvent struct {
+	gorm.Model
+	ApplicationID uint       `json:"applicationId" gorm:"index;not null"`
+	ActorID       *uint      `json:"actorId"` // Nil for system-driven transitions
+	Actor         *user.User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	ActorRole     string     `json:"actorRole"`
+	FromStatus    string     `json:"fromStatus"`
+	ToStatus      string     `json:"toStatus"`
+	Reason        string     `json:"reason" gorm:"type:text"`
+}
+
+// ApplicationRevision is one version of an application's statement, attachments and answers
+type ApplicationRevision struct {
+	gorm.Model
+	ApplicationID uint           `json:"applicationId" gorm:"uniqueIndex:idx_application_revision;not null"`
+	Version       int            `json:"version" gorm:"uniqueIndex:idx_application_revision;not null"`
+	EditorID      *uint          `json:"editorId"` // Nil for versions reconstructed from older applications
+	Statement     string         `json:"statement" gorm:"type:text"`
+	Attachments   datatypes.JSON `json:"attachments" gorm:"type:json"`
+	Answers       datatypes.JSON `json:"answers" gorm:"type:json"`
+}
+
+// ScoringWeights holds an organization's weights for the automatic application score.
+// Organizations without a row use DefaultScoringWeights.
+type ScoringWeights struct {
+	gorm.Model
+	OrganizationID uint    `json:"organizationId" gorm:"uniqueIndex;not null"`
+	SkillMatch     float64 `json:"skillMatch"`
+	Portfolio      float64 `json:"portfolio"`
+	Rating         float64 `json:"rating"`
+	OnTime         float64 `json:"onTime"`
+	Rework         float64 `json:"rework"`
+	Screening      float64 `json:"screening"`
+}
+
+// ApplicationMember links a student to an application. It mirrors Application.StudentIDs so
+// membership can be queried and constrained without parsing JSON.
+type ApplicationMember struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"createdAt"`
+	ApplicationID uint      `json:"applicationId" gorm:"uniqueIndex:idx_application_member;not null"`
+	ProjectID     uint      `json:"projectId" gorm:"index;not null"`
+	UserID        uint      `json:"userId" gorm:"uniqueIndex:idx_application_member;index;not null"`
 }
@@ -0,0 +1,44 @@
+package application
+
+import (
+	"encoding/json"
+	"log"
+
+	notification "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Notification"
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"gorm.io/gorm"
+)
+
+// applicationStudentIDs returns the user IDs of the students on an application
+func applicationStudentIDs(application Application) []uint {
+	var studentIDs []uint
+	if len(application.StudentIDs) > 0 {
+		json.Unmarshal(application.StudentIDs, &studentIDs)
+	}
+	return studentIDs
+}
+
+// notifyApplicants sends an in-app notification and an email to every student on an application.
+// Failures are logged and never fail the caller.
+func notifyApplicants(db *gorm.DB, application Application, notifType, title, message, link string) {
+	studentIDs := applicationStudentIDs(application)
+	if len(studentIDs) == 0 {
+		return
+	}
+
+	if err := notification.Notify(db, studentIDs, notifType, title, message, link); err != nil {
+		log.Printf("Warning: failed to notify applicants of application %d: %v", application.ID, err)
+	}
+
+	var students []user.User
+	if err := db.Where("id IN ?", studentIDs).Find(&students).Error; err != nil {
+		log.Printf("Warning: failed to load applicants of application %d: %v", application.ID, err)
+		return
+	}
+
+	for _, student := range students {
+		if err := SendApplicationEmail(student.Email, student.Name, title, message, link); err != nil {
+			log.Printf("Warning: failed to email %s about application %d: %v", student.Email, application.ID, err)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Defaults for the offer flow, overridable through OFFER_EXPIRY_HOURS and OFFER_SWEEP_INTERVAL_MINUTES
+const (
+	defaultOfferExpiryHours         = 72
+	defaultOfferSweepIntervalMinute = 15
+)
+
+// offerExpiryWindow returns how long a student has to accept an offer
+func offerExpiryWindow() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("OFFER_EXPIRY_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = defaultOfferExpiryHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// offerSweepInterval returns how often stale offers are expired
+func offerSweepInterval() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("OFFER_SWEEP_INTERVAL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = defaultOfferSweepIntervalMinute
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// makeOffer moves an application to OFFERED with a fresh expiry.
+// actorID is 0 when the offer comes from a waitlist promotion.
+func makeOffer(tx *gorm.DB, application *Application, actorID uint, actorRole, reason string) error {
+	expiresAt := time.Now().Add(offerExpiryWindow())
+	application.OfferExpiresAt = &expiresAt
+	return changeStatus(tx, application, StatusOffered, actorID, actorRole, reason)
+}
+
+// notifyOffer tells the students on an application that they have an offer waiting
+func notifyOffer(db *gorm.DB, application Application) {
+	message := fmt.Sprintf("You have received an offer for \"%s\".", application.Project.Title)
+	if application.OfferExpiresAt != nil {
+		message += fmt.Sprintf(" Please accept or decline it before %s.", application.OfferExpiresAt.Format("Jan 2, 2006 15:04 MST"))
+	}
+	notifyApplicants(db, application, "offer_received", "New Offer Received", message, "/offers")
+}
+
+// ensureApplicationGroup makes sure an application has a chat group containing all of its students,
+// creating one (led by the first student) if it has none or its group was deleted. It returns the students
+// added to a new group, for user.MembershipChanged once the transaction commits.
+func ensureApplicationGroup(tx *gorm.DB, application *Application) ([]uint, error) {
+	if application.GroupID != nil {
+		var groupCount int64
+		if err := tx.Model(&user.Group{}).Where("id = ?", application.GroupID).Count(&groupCount).Error; err != nil {
+			return nil, fmt.Errorf("failed to validate group")
+		}
+		if groupCount > 0 {
+			return nil, nil
+		}
+	}
+
+	studentIDs := applicationStudentIDs(*application)
+	if len(studentIDs) == 0 {
+		return nil, fmt.Errorf("application must have at least one student")
+	}
+
+	// Get the first student (leader)
+	var student user.User
+	if err := tx.First(&student, studentIDs[0]).Error; err != nil {
+		return nil, fmt.Errorf("student not found")
+	}
+
+	groupName := fmt.Sprintf("%s - %s", student.Name, application.Project.Title)
+	if len(groupName) > 100 {
+		groupName = groupName[:100] // Truncate if too long
+	}
+
+	group := user.Group{
+		UserID:   studentIDs[0], // First student is the leader
+		Name:     groupName,
+		Capacity: len(studentIDs),
+	}
+
+	if err := tx.Create(&group).Error; err != nil {
+		return nil, fmt.Errorf("failed to create group for application: %w", err)
+	}
+
+	// Add all students as members
+	var members []user.User
+	if err := tx.Where("id IN ?", studentIDs).Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to load group members: %w", err)
+	}
+	if len(members) > 0 {
+		if err := tx.Model(&group).Association("Members").Append(members); err != nil {
+			return nil, fmt.Errorf("failed to add members to group: %w", err)
+		}
+	}
+
+	application.GroupID = &group.ID
+	joined := make([]uint, 0, len(members))
+	for _, member := range members {
+		joined = append(joined, member.ID)
+	}
+	return joined, nil
+}
+
+// errNoSeats is returned when a team no longer fits in its project's remaining capacity
+var errNoSeats = errors.New("this project does not have enough seats left for this team")
+
+// hasSeatFor locks the application's project and reports whether the application's team still fits
+// in the project's capacity. It must be called inside a transaction.
+func hasSeatFor(tx *gorm.DB, application Application) (bool, error) {
+	proj, err := project.LockForAssignment(tx, application.ProjectID)
+	if err != nil {
+		return false, err
+	}
+	seats, err := project.GetCapacitySummary(tx, proj)
+	if err != nil {
+		return false, err
+	}
+	return seats.CanSeat(len(applicationStudentIDs(application))), nil
+}
+
+// promoteWaitlisted offers the seats freed on a project to waitlisted applications, longest-waiting
+// first, skipping teams too large for the seats that remain. It must be called inside a transaction.
+func promoteWaitlisted(tx *gorm.DB, projectID uint) ([]Application, error) {
+	proj, err := project.LockForAssignment(tx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var waitlisted []Application
+	if err := tx.Model(&Application{}).
+		Select("applications.*").
+		Joins("LEFT JOIN application_status_events ON application_status_events.application_id = applications.id AND application_status_events.to_status = ?", StatusWaitlist).
+		Where("applications.project_id = ? AND applications.status = ?", projectID, StatusWaitlist).
+		Group("applications.id").
+		Order("MAX(COALESCE(application_status_events.created_at, applications.created_at)) ASC").
+		Find(&waitlisted).Error; err != nil {
+		return nil, err
+	}
+
+	var promoted []Application
+	for i := range waitlisted {
+		seats, err := project.GetCapacitySummary(tx, proj)
+		if err != nil {
+			return nil, err
+		}
+		if seats.Full {
+			break
+		}
+		if !seats.CanSeat(len(applicationStudentIDs(waitlisted[i]))) {
+			continue
+		}
+
+		next := waitlisted[i]
+		next.Project = proj
+		if err := makeOffer(tx, &next, 0, "system", "promoted from waitlist"); err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, next)
+	}
+
+	return promoted, nil
+}
+
+// expireStaleOffers expires every offer past its OfferExpiresAt and promotes the next waitlisted
+// application on the same project
+func expireStaleOffers(db *gorm.DB) {
+	var staleIDs []uint
+	if err := db.Model(&Application{}).
+		Where("status = ? AND offer_expires_at IS NOT NULL AND offer_expires_at < ?", StatusOffered, time.Now()).
+		Pluck("id", &staleIDs).Error; err != nil {
+		log.Printf("Offer sweeper: failed to find stale offers: %v", err)
+		return
+	}
+
+	for _, id := range staleIDs {
+		var expired Application
+		var promoted []Application
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Lock the row so concurrent sweepers or an accept in flight cannot double-process it
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Preload("Project").
+				First(&expired, id).Error; err != nil {
+				return err
+			}
+			if expired.Status != StatusOffered {
+				return nil
+			}
+
+			if err := changeStatus(tx, &expired, StatusExpired, 0, "system", "offer expired"); err != nil {
+				return err
+			}
+
+			var err error
+			promoted, err = promoteWaitlisted(tx, expired.ProjectID)
+			return err
+		})
+		if err == gorm.ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			log.Printf("Offer sweeper: failed to expire offer on application %d: %v", id, err)
+			continue
+		}
+
+		if expired.Status == StatusExpired {
+			notifyApplicants(db, expired, "application_status", "Offer Expired",
+				fmt.Sprintf("Your offer for \"%s\" has expired.", expired.Project.Title), "/applications")
+		}
+		for _, app := range promoted {
+			notifyOffer(db, app)
+		}
+	}
+}
+
+// StartOfferSweeper starts the background job that expires stale offers (call this from main.go)
+func StartOfferSweeper(db *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(offerSweepInterval())
+		defer ticker.Stop()
+		for {
+			expireStaleOffers(db)
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,215 @@
+package application
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// revealedStatuses are the statuses at which a blind-reviewed application shows who applied
+var revealedStatuses = []string{StatusShortlisted, StatusOffered, StatusAccepted, StatusDeclined, StatusAssigned, StatusExpired}
+
+// candidateNumbers returns the stable position of each application within its project.
+// Soft-deleted applications keep their numbers so later candidates are never renumbered.
+func candidateNumbers(db *gorm.DB, projectIDs []uint) (map[uint]int, error) {
+	var rows []struct {
+		ID     uint
+		Number int
+	}
+	if err := db.Unscoped().Model(&Application{}).
+		Select("id, ROW_NUMBER() OVER (PARTITION BY project_id ORDER BY id) AS number").
+		Where("project_id IN ?", projectIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	numbers := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		numbers[row.ID] = row.Number
+	}
+	return numbers, nil
+}
+
+// revealedApplicationIDs returns which of the given applications have ever been shortlisted or offered
+func revealedApplicationIDs(db *gorm.DB, applications []Application) (map[uint]bool, error) {
+	revealed := make(map[uint]bool, len(applications))
+	var ids []uint
+	for _, app := range applications {
+		for _, status := range revealedStatuses {
+			if app.Status == status {
+				revealed[app.ID] = true
+			}
+		}
+		ids = append(ids, app.ID)
+	}
+
+	var eventIDs []uint
+	if err := db.Model(&ApplicationStatusEvent{}).
+		Where("application_id IN ? AND to_status IN ?", ids, []string{StatusShortlisted, StatusOffered}).
+		Distinct().
+		Pluck("application_id", &eventIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range eventIDs {
+		revealed[id] = true
+	}
+	return revealed, nil
+}
+
+// loadApplicantProfiles loads the name, email, gender, district and avatar of the given students
+func loadApplicantProfiles(db *gorm.DB, userIDs []uint) (map[uint]ApplicantProfile, error) {
+	var rows []struct {
+		ID            uint
+		Name          string
+		Email         string
+		ProfileAvatar string
+		Gender        string
+		District      string
+	}
+	if err := db.Table("users").
+		Select("users.id, users.name, users.email, users.profile_avatar, students.gender, students.district").
+		Joins("LEFT JOIN students ON students.user_id = users.id AND students.deleted_at IS NULL").
+		Where("users.id IN ? AND users.deleted_at IS NULL", userIDs).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	profiles := make(map[uint]ApplicantProfile, len(rows))
+	for _, row := range rows {
+		profiles[row.ID] = ApplicantProfile{
+			UserID:   row.ID,
+			Name:     row.Name,
+			Email:    row.Email,
+			Gender:   row.Gender,
+			District: row.District,
+			Avatar:   row.ProfileAvatar,
+		}
+	}
+	return profiles, nil
+}
+
+// anonymize replaces everything that identifies the students on an application with its candidate handle
+func anonymize(application *Application) {
+	count := len(applicationStudentIDs(*application))
+	application.Applicants = make([]ApplicantProfile, 0, count)
+	for i := 0; i < count; i++ {
+		name := application.CandidateLabel
+		if count > 1 {
+			name = fmt.Sprintf("%s, member %d", application.CandidateLabel, i+1)
+		}
+		application.Applicants = append(application.Applicants, ApplicantProfile{Name: name})
+	}
+
+	application.StudentIDs = nil
+	application.GroupID = nil
+	application.Group = nil
+	application.Anonymized = true
+}
+
+// hidesApplicants reports whether a viewer must see an application anonymized
+func hidesApplicants(application Application, role string, revealed map[uint]bool) bool {
+	return role == "partner" && application.Project.BlindReview && !revealed[application.ID]
+}
+
+// prepareForViewer attaches applicant profiles and candidate handles to applications loaded with
+// their Project. Partners see anonymized applicants on blind-review projects until shortlisting.
+func prepareForViewer(db *gorm.DB, applications []Application, role string) error {
+	if len(applications) == 0 {
+		return nil
+	}
+
+	var projectIDs, userIDs []uint
+	for _, app := range applications {
+		projectIDs = append(projectIDs, app.ProjectID)
+		userIDs = append(userIDs, applicationStudentIDs(app)...)
+	}
+
+	numbers, err := candidateNumbers(db, projectIDs)
+	if err != nil {
+		return err
+	}
+	profiles, err := loadApplicantProfiles(db, userIDs)
+	if err != nil {
+		return err
+	}
+	revealed, err := revealedApplicationIDs(db, applications)
+	if err != nil {
+		return err
+	}
+
+	for i := range applications {
+		app := &applications[i]
+		app.CandidateLabel = fmt.Sprintf("Candidate %d", numbers[app.ID])
+
+		if hidesApplicants(*app, role, revealed) {
+			anonymize(app)
+			continue
+		}
+
+		for _, sid := range applicationStudentIDs(*app) {
+			if profile, ok := profiles[sid]; ok {
+				app.Applicants = append(app.Applicants, profile)
+			}
+		}
+	}
+	return nil
+}
+
+// reloadForViewer reloads an application with its project and group after a change and prepares it for the
+// viewer, so responses to actions are anonymized the same way as listings
+func reloadForViewer(db *gorm.DB, application *Application, role string) error {
+	var reloaded Application
+	if err := db.Preload("Project").Preload("Group").Preload("Group.Members").First(&reloaded, application.ID).Error; err != nil {
+		return err
+	}
+	applications := []Application{reloaded}
+	if err := prepareForViewer(db, applications, role); err != nil {
+		return err
+	}
+	*application = applications[0]
+	return nil
+}
+
+// hidesApplicantsFrom reports whether a viewer must not see who applied to a single application loaded
+// with its Project
+func hidesApplicantsFrom(db *gorm.DB, application Application, role string) (bool, error) {
+	if role != "partner" || !application.Project.BlindReview {
+		return false, nil
+	}
+	revealed, err := revealedApplicationIDs(db, []Application{application})
+	if err != nil {
+		return false, err
+	}
+	return hidesApplicants(application, role, revealed), nil
+}
+
+// redactHistory hides the students acting on a blind-reviewed application's status events from viewers
+// who must not see who applied
+func redactHistory(db *gorm.DB, application Application, events []ApplicationStatusEvent, role string) error {
+	hidden, err := hidesApplicantsFrom(db, application, role)
+	if err != nil || !hidden {
+		return err
+	}
+	for i := range events {
+		if events[i].ActorRole == "student" {
+			events[i].ActorID = nil
+			events[i].Actor = nil
+		}
+	}
+	return nil
+}
+
+// redactRevisions hides who edited a blind-reviewed application from viewers who must not see who
+// applied. The viewer's own edits keep their editor.
+func redactRevisions(db *gorm.DB, application Application, revisions []ApplicationRevision, userID uint, role string) error {
+	hidden, err := hidesApplicantsFrom(db, application, role)
+	if err != nil || !hidden {
+		return err
+	}
+	for i := range revisions {
+		if revisions[i].EditorID != nil && *revisions[i].EditorID != userID {
+			revisions[i].EditorID = nil
+		}
+	}
+	return nil
+}
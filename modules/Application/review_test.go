@@ -0,0 +1,85 @@
+package application
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"gorm.io/datatypes"
+)
+
+func TestHidesApplicants(t *testing.T) {
+	blind := Application{Project: project.Project{BlindReview: true}}
+	blind.ID = 1
+	open := Application{Project: project.Project{BlindReview: false}}
+	open.ID = 2
+
+	tests := []struct {
+		name        string
+		application Application
+		role        string
+		revealed    map[uint]bool
+		want        bool
+	}{
+		{"partner on blind project", blind, "partner", nil, true},
+		{"partner after shortlisting", blind, "partner", map[uint]bool{1: true}, false},
+		{"partner on open project", open, "partner", nil, false},
+		{"student", blind, "student", nil, false},
+		{"university admin", blind, "university-admin", nil, false},
+		{"super-admin", blind, "super-admin", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hidesApplicants(tt.application, tt.role, tt.revealed); got != tt.want {
+				t.Errorf("hidesApplicants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	groupID := uint(7)
+	tests := []struct {
+		name       string
+		studentIDs []uint
+		wantNames  []string
+	}{
+		{"individual", []uint{10}, []string{"Candidate 3"}},
+		{"group", []uint{10, 11}, []string{"Candidate 3, member 1", "Candidate 3, member 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, _ := json.Marshal(tt.studentIDs)
+			application := Application{
+				StudentIDs:     datatypes.JSON(ids),
+				GroupID:        &groupID,
+				Group:          &user.Group{Name: "Jane Doe - Website redesign"},
+				CandidateLabel: "Candidate 3",
+			}
+
+			anonymize(&application)
+
+			if !application.Anonymized {
+				t.Error("application is not marked anonymized")
+			}
+			if application.StudentIDs != nil || application.GroupID != nil || application.Group != nil {
+				t.Error("student IDs or group are still exposed")
+			}
+			if len(application.Applicants) != len(tt.wantNames) {
+				t.Fatalf("got %d applicants, want %d", len(application.Applicants), len(tt.wantNames))
+			}
+			for i, applicant := range application.Applicants {
+				if applicant.Name != tt.wantNames[i] || applicant.Email != "" || applicant.UserID != 0 {
+					t.Errorf("applicant %d = %+v, want only the name %q", i, applicant, tt.wantNames[i])
+				}
+			}
+
+			body, _ := json.Marshal(application)
+			if strings.Contains(string(body), "Jane Doe") {
+				t.Error("serialized application still contains the student's name")
+			}
+		})
+	}
+}
@@ -14,6 +14,19 @@ func RegisterRoutes(r fiber.Router, db *gorm.DB) {
 		return GetAll(c, db)
 	})
 
+	// Scoring weights (must come before /:id)
+	applications.Get("/scoring-weights", func(c *fiber.Ctx) error {
+		return GetScoringWeights(c, db)
+	})
+
+	applications.Put("/scoring-weights", func(c *fiber.Ctx) error {
+		return UpdateScoringWeights(c, db)
+	})
+
+	applications.Post("/bulk-action", func(c *fiber.Ctx) error {
+		return BulkAction(c, db)
+	})
+
 	applications.Get("/:id", func(c *fiber.Ctx) error {
 		return GetByID(c, db)
 	})
@@ -26,6 +39,22 @@ func RegisterRoutes(r fiber.Router, db *gorm.DB) {
 		return Update(c, db)
 	})
 
+	applications.Get("/:id/history", func(c *fiber.Ctx) error {
+		return GetHistory(c, db)
+	})
+
+	applications.Get("/:id/revisions", func(c *fiber.Ctx) error {
+		return GetRevisions(c, db)
+	})
+
+	applications.Post("/:id/withdraw", func(c *fiber.Ctx) error {
+		return Withdraw(c, db)
+	})
+
+	applications.Post("/:id/resubmit", func(c *fiber.Ctx) error {
+		return Resubmit(c, db)
+	})
+
 	applications.Delete("/:id", func(c *fiber.Ctx) error {
 		return Delete(c, db)
 	})
@@ -35,6 +64,10 @@ func RegisterRoutes(r fiber.Router, db *gorm.DB) {
 	})
 
 	// Screening endpoints (university admin actions)
+	applications.Post("/rescore", func(c *fiber.Ctx) error {
+		return Rescore(c, db)
+	})
+
 	applications.Post("/:id/score", func(c *fiber.Ctx) error {
 		return ScoreApplication(c, db)
 	})
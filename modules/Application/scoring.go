@@ -0,0 +1,418 @@
+package application
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	portfolio "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Portfolio"
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	review "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Review"
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// DefaultScoringWeights are used for organizations that have not configured their own weights
+var DefaultScoringWeights = ScoringWeights{
+	SkillMatch: 0.4,
+	Portfolio:  0.2,
+	Rating:     0.2,
+	OnTime:     0.1,
+	Rework:     0.1,
+	Screening:  0.1,
+}
+
+// portfolioVolumeTarget is the number of portfolio items at which a student's portfolio counts in full
+const portfolioVolumeTarget = 5
+
+// complexityPoints maps portfolio complexity to points out of 3
+var complexityPoints = map[string]float64{"LOW": 1, "MEDIUM": 2, "HIGH": 3}
+
+// scoreComponents are the server-computed parts of Application.Score, each on a 0-100 scale
+type scoreComponents struct {
+	SkillMatch     float64
+	PortfolioScore float64
+	RatingScore    float64
+	OnTimeRate     float64
+	ReworkRate     float64
+	ScreeningScore float64
+	HasScreening   bool // Screening only counts on projects with preferred answers
+}
+
+// getScoringWeights returns the weights configured for an organization, falling back to the defaults
+func getScoringWeights(db *gorm.DB, orgID uint) ScoringWeights {
+	var weights ScoringWeights
+	if orgID == 0 {
+		return DefaultScoringWeights
+	}
+	if err := db.Where("organization_id = ?", orgID).First(&weights).Error; err != nil {
+		return DefaultScoringWeights
+	}
+	return weights
+}
+
+// projectOrganizationID returns the university that owns the project's department
+func projectOrganizationID(db *gorm.DB, proj project.Project) uint {
+	var orgID uint
+	db.Table("departments").
+		Where("id = ?", proj.DepartmentID).
+		Select("organization_id").
+		Scan(&orgID)
+	return orgID
+}
+
+// parseStringList decodes a JSON array of strings, returning nil for empty or malformed input
+func parseStringList(data datatypes.JSON) []string {
+	var list []string
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil
+	}
+	return list
+}
+
+// computeSkillMatch returns the percentage of project skills covered by at least one applicant
+func computeSkillMatch(projectSkills []string, students []user.User) float64 {
+	if len(projectSkills) == 0 {
+		return 0
+	}
+
+	have := make(map[string]bool)
+	for _, s := range students {
+		for _, skill := range parseStringList(s.Profile.Skills) {
+			have[strings.ToLower(strings.TrimSpace(skill))] = true
+		}
+	}
+
+	matched := 0
+	for _, skill := range projectSkills {
+		if have[strings.ToLower(strings.TrimSpace(skill))] {
+			matched++
+		}
+	}
+
+	return float64(matched) / float64(len(projectSkills)) * 100
+}
+
+// computePortfolioComponents aggregates the portfolio items and partner reviews of every applicant.
+// portfolioScore is the average complexity scaled by how close the team is to portfolioVolumeTarget
+// items per student, ratingScore is the average partner rating out of 5 across portfolio items and
+// reviews, and onTimeRate the share of items delivered on time.
+func computePortfolioComponents(items []portfolio.PortfolioItem, reviews review.Summary, studentCount int) (portfolioScore, ratingScore, onTimeRate float64) {
+	if studentCount == 0 {
+		return 0, 0, 0
+	}
+
+	complexityTotal := 0.0
+	ratingTotal := reviews.Overall * float64(reviews.Count)
+	ratedCount := int(reviews.Count)
+	onTimeCount := 0
+	for _, item := range items {
+		complexityTotal += complexityPoints[item.Complexity]
+		if item.Rating != nil {
+			ratingTotal += *item.Rating
+			ratedCount++
+		}
+		if item.OnTime {
+			onTimeCount++
+		}
+	}
+
+	if ratedCount > 0 {
+		ratingScore = ratingTotal / float64(ratedCount) / 5 * 100
+	}
+	if len(items) == 0 {
+		return 0, ratingScore, 0
+	}
+
+	volume := math.Min(float64(len(items))/float64(studentCount*portfolioVolumeTarget), 1)
+	portfolioScore = complexityTotal / float64(len(items)) / 3 * 100 * volume
+	onTimeRate = float64(onTimeCount) / float64(len(items)) * 100
+
+	return portfolioScore, ratingScore, onTimeRate
+}
+
+// computeReworkRate returns the share of milestone submissions by the students' teams that were
+// resubmissions after changes were requested
+func computeReworkRate(db *gorm.DB, studentIDs []uint) (float64, error) {
+	var counts struct {
+		Total  int64
+		Rework int64
+	}
+	if err := db.Table("milestone_submissions").
+		Select("COUNT(DISTINCT milestone_submissions.id) AS total, COUNT(DISTINCT CASE WHEN milestone_submissions.revision > 1 THEN milestone_submissions.id END) AS rework").
+		Joins("JOIN user_groups ON user_groups.group_id = milestone_submissions.group_id").
+		Where("user_groups.user_id IN ? AND milestone_submissions.deleted_at IS NULL", studentIDs).
+		Scan(&counts).Error; err != nil {
+		return 0, err
+	}
+	if counts.Total == 0 {
+		return 0, nil
+	}
+	return float64(counts.Rework) / float64(counts.Total) * 100, nil
+}
+
+// combineScore weights the components into a single 0-100 score.
+// Rework counts against the applicant, so its complement is used.
+func combineScore(components scoreComponents, weights ScoringWeights) float64 {
+	total := weights.SkillMatch + weights.Portfolio + weights.Rating + weights.OnTime + weights.Rework
+	score := weights.SkillMatch*components.SkillMatch +
+		weights.Portfolio*components.PortfolioScore +
+		weights.Rating*components.RatingScore +
+		weights.OnTime*components.OnTimeRate +
+		weights.Rework*(100-components.ReworkRate)
+
+	if components.HasScreening {
+		total += weights.Screening
+		score += weights.Screening * components.ScreeningScore
+	}
+
+	if total <= 0 {
+		return 0
+	}
+	return score / total
+}
+
+// roundScore rounds a score to two decimal places
+func roundScore(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// applyAutoScore computes the automatic score for an application and merges it into Application.Score,
+// keeping any manual fields (e.g. manualSupervisorScore, finalScore) already present.
+// The application must be loaded with its Project; it is not saved.
+func applyAutoScore(db *gorm.DB, application *Application) error {
+	var studentIDs []uint
+	if len(application.StudentIDs) > 0 {
+		if err := json.Unmarshal(application.StudentIDs, &studentIDs); err != nil {
+			return err
+		}
+	}
+
+	var students []user.User
+	var items []portfolio.PortfolioItem
+	var reviews review.Summary
+	if len(studentIDs) > 0 {
+		if err := db.Where("id IN ?", studentIDs).Find(&students).Error; err != nil {
+			return err
+		}
+		if err := db.Where("user_id IN ? AND verified = ?", studentIDs, true).Find(&items).Error; err != nil {
+			return err
+		}
+		summary, err := review.SummaryFor(db, studentIDs, review.DirectionPartnerToStudent)
+		if err != nil {
+			return err
+		}
+		reviews = summary
+	}
+
+	components := scoreComponents{
+		SkillMatch: computeSkillMatch(parseStringList(application.Project.Skills), students),
+	}
+	components.PortfolioScore, components.RatingScore, components.OnTimeRate = computePortfolioComponents(items, reviews, len(studentIDs))
+	if len(studentIDs) > 0 {
+		reworkRate, err := computeReworkRate(db, studentIDs)
+		if err != nil {
+			return err
+		}
+		components.ReworkRate = reworkRate
+	}
+	components.ScreeningScore, components.HasScreening = computeScreeningScore(application.Project.GetScreeningQuestions(), parseAnswers(application.Answers))
+
+	weights := getScoringWeights(db, projectOrganizationID(db, application.Project))
+	autoScore := combineScore(components, weights)
+
+	score := map[string]interface{}{}
+	if len(application.Score) > 0 {
+		json.Unmarshal(application.Score, &score)
+	}
+	score["autoScore"] = roundScore(autoScore)
+	score["skillMatch"] = roundScore(components.SkillMatch)
+	score["portfolioScore"] = roundScore(components.PortfolioScore)
+	score["ratingScore"] = roundScore(components.RatingScore)
+	score["onTimeRate"] = roundScore(components.OnTimeRate)
+	score["reworkRate"] = roundScore(components.ReworkRate)
+	if components.HasScreening {
+		score["screeningScore"] = roundScore(components.ScreeningScore)
+	} else {
+		delete(score, "screeningScore")
+	}
+
+	scoreJSON, err := json.Marshal(score)
+	if err != nil {
+		return err
+	}
+	application.Score = datatypes.JSON(scoreJSON)
+	application.PortfolioScore = roundScore(components.PortfolioScore)
+
+	return nil
+}
+
+// rescoreApplication recomputes and persists the automatic score of a single application
+func rescoreApplication(db *gorm.DB, application *Application) error {
+	if err := applyAutoScore(db, application); err != nil {
+		return err
+	}
+	return db.Model(application).Updates(map[string]interface{}{
+		"score":           application.Score,
+		"portfolio_score": application.PortfolioScore,
+	}).Error
+}
+
+// Rescore recomputes the automatic score of every application for a project
+func Rescore(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	projectID, err := strconv.ParseUint(c.Query("projectId"), 10, 32)
+	if err != nil || projectID == 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "projectId is required"})
+	}
+
+	var proj project.Project
+	if err := db.First(&proj, projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "project not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get project: " + err.Error()})
+	}
+
+	if !canManageApplication(db, Application{Project: proj}, userID, role) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to rescore applications for this project"})
+	}
+
+	var applications []Application
+	if err := db.Where("project_id = ?", proj.ID).Preload("Project").Find(&applications).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get applications: " + err.Error()})
+	}
+
+	for i := range applications {
+		if err := rescoreApplication(db, &applications[i]); err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "failed to rescore application " + strconv.FormatUint(uint64(applications[i].ID), 10) + ": " + err.Error()})
+		}
+	}
+	if err := prepareForViewer(db, applications, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"msg":  "applications rescored successfully",
+		"data": applications,
+	})
+}
+
+// scoringWeightsOrganizationID resolves which organization's weights the caller is reading or changing.
+// University admins always act on their own organization; super-admins pass ?organizationId=.
+func scoringWeightsOrganizationID(c *fiber.Ctx, db *gorm.DB) (uint, bool) {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	if role == "super-admin" {
+		orgID, err := strconv.ParseUint(c.Query("organizationId"), 10, 32)
+		if err != nil || orgID == 0 {
+			return 0, false
+		}
+		return uint(orgID), true
+	}
+
+	if role == "university-admin" || role == "delegated-admin" {
+		orgID, err := getOrganizationIDForAdmin(db, userID, role)
+		if err != nil || orgID == 0 {
+			return 0, false
+		}
+		return orgID, true
+	}
+
+	return 0, false
+}
+
+// GetScoringWeights returns the scoring weights for the caller's organization
+func GetScoringWeights(c *fiber.Ctx, db *gorm.DB) error {
+	orgID, ok := scoringWeightsOrganizationID(c, db)
+	if !ok {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to view scoring weights"})
+	}
+
+	weights := getScoringWeights(db, orgID)
+	weights.OrganizationID = orgID
+
+	return c.JSON(fiber.Map{"data": weights})
+}
+
+// UpdateScoringWeights sets the scoring weights for the caller's organization
+func UpdateScoringWeights(c *fiber.Ctx, db *gorm.DB) error {
+	orgID, ok := scoringWeightsOrganizationID(c, db)
+	if !ok {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to update scoring weights"})
+	}
+
+	type WeightsRequest struct {
+		SkillMatch *float64 `json:"skillMatch"`
+		Portfolio  *float64 `json:"portfolio"`
+		Rating     *float64 `json:"rating"`
+		OnTime     *float64 `json:"onTime"`
+		Rework     *float64 `json:"rework"`
+		Screening  *float64 `json:"screening"`
+	}
+
+	var req WeightsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid scoring weights: " + err.Error()})
+	}
+
+	// Validate weights
+	for _, w := range []*float64{req.SkillMatch, req.Portfolio, req.Rating, req.OnTime, req.Rework, req.Screening} {
+		if w != nil && *w < 0 {
+			return c.Status(400).JSON(fiber.Map{"msg": "scoring weights cannot be negative"})
+		}
+	}
+
+	// Update only provided weights
+	weights := getScoringWeights(db, orgID)
+	weights.OrganizationID = orgID
+	if req.SkillMatch != nil {
+		weights.SkillMatch = *req.SkillMatch
+	}
+	if req.Portfolio != nil {
+		weights.Portfolio = *req.Portfolio
+	}
+	if req.Rating != nil {
+		weights.Rating = *req.Rating
+	}
+	if req.OnTime != nil {
+		weights.OnTime = *req.OnTime
+	}
+	if req.Rework != nil {
+		weights.Rework = *req.Rework
+	}
+	if req.Screening != nil {
+		weights.Screening = *req.Screening
+	}
+
+	if weights.SkillMatch+weights.Portfolio+weights.Rating+weights.OnTime+weights.Rework+weights.Screening <= 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "at least one scoring weight must be positive"})
+	}
+
+	if err := db.Save(&weights).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to save scoring weights: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"msg":  "scoring weights updated successfully",
+		"data": weights,
+	})
+}
+
+// scoreOnCreate computes the automatic score right after an application is submitted.
+// Scoring problems are logged rather than failing the submission.
+func scoreOnCreate(db *gorm.DB, application *Application) {
+	if err := rescoreApplication(db, application); err != nil {
+		log.Printf("Warning: failed to score application %d: %v", application.ID, err)
+	}
+}
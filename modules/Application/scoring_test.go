@@ -0,0 +1,115 @@
+package application
+
+import (
+	"math"
+	"testing"
+
+	portfolio "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Portfolio"
+	review "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Review"
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"gorm.io/datatypes"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func studentWithSkills(skills string) user.User {
+	var student user.User
+	student.Profile.Skills = datatypes.JSON(skills)
+	return student
+}
+
+func TestComputeSkillMatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		projectSkills []string
+		students      []user.User
+		want          float64
+	}{
+		{"no project skills", nil, []user.User{studentWithSkills(`["Go"]`)}, 0},
+		{"no applicants", []string{"Go"}, nil, 0},
+		{"full match ignoring case and spaces", []string{"Go", " React "}, []user.User{studentWithSkills(`["go","react"]`)}, 100},
+		{"partial match", []string{"Go", "React", "SQL", "Figma"}, []user.User{studentWithSkills(`["Go"]`)}, 25},
+		{"team covers skills together", []string{"Go", "React"}, []user.User{studentWithSkills(`["Go"]`), studentWithSkills(`["React"]`)}, 100},
+		{"malformed skills", []string{"Go"}, []user.User{studentWithSkills(`not json`)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeSkillMatch(tt.projectSkills, tt.students); !approxEqual(got, tt.want) {
+				t.Errorf("computeSkillMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputePortfolioComponents(t *testing.T) {
+	rating := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name          string
+		items         []portfolio.PortfolioItem
+		reviews       review.Summary
+		studentCount  int
+		wantPortfolio float64
+		wantRating    float64
+		wantOnTime    float64
+	}{
+		{"no students", []portfolio.PortfolioItem{{Complexity: "HIGH"}}, review.Summary{}, 0, 0, 0, 0},
+		{"no history", nil, review.Summary{}, 1, 0, 0, 0},
+		{"reviews only", nil, review.Summary{Count: 2, Overall: 4}, 1, 0, 80, 0},
+		{
+			"full volume of high complexity work",
+			[]portfolio.PortfolioItem{
+				{Complexity: "HIGH", OnTime: true}, {Complexity: "HIGH", OnTime: true}, {Complexity: "HIGH", OnTime: true},
+				{Complexity: "HIGH", OnTime: true}, {Complexity: "HIGH", OnTime: false},
+			},
+			review.Summary{}, 1, 100, 0, 80,
+		},
+		{
+			"volume scales with team size",
+			[]portfolio.PortfolioItem{{Complexity: "MEDIUM", OnTime: true, Rating: rating(5)}},
+			review.Summary{}, 2, 2.0 / 3 * 100 * 0.1, 100, 100,
+		},
+		{
+			"ratings combine items and reviews",
+			[]portfolio.PortfolioItem{{Complexity: "LOW", Rating: rating(2)}},
+			review.Summary{Count: 1, Overall: 4}, 1, 1.0 / 3 * 100 * 0.2, 60, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			portfolioScore, ratingScore, onTimeRate := computePortfolioComponents(tt.items, tt.reviews, tt.studentCount)
+			if !approxEqual(portfolioScore, tt.wantPortfolio) || !approxEqual(ratingScore, tt.wantRating) || !approxEqual(onTimeRate, tt.wantOnTime) {
+				t.Errorf("computePortfolioComponents() = (%v, %v, %v), want (%v, %v, %v)",
+					portfolioScore, ratingScore, onTimeRate, tt.wantPortfolio, tt.wantRating, tt.wantOnTime)
+			}
+		})
+	}
+}
+
+func TestCombineScore(t *testing.T) {
+	perfect := scoreComponents{SkillMatch: 100, PortfolioScore: 100, RatingScore: 100, OnTimeRate: 100, ReworkRate: 0}
+
+	tests := []struct {
+		name       string
+		components scoreComponents
+		weights    ScoringWeights
+		want       float64
+	}{
+		{"perfect applicant", perfect, DefaultScoringWeights, 100},
+		{"rework counts against the applicant", scoreComponents{ReworkRate: 100}, DefaultScoringWeights, 0},
+		{"no rework history counts in full", scoreComponents{}, DefaultScoringWeights, 10},
+		{"skill match only", scoreComponents{SkillMatch: 50}, ScoringWeights{SkillMatch: 1}, 50},
+		{"screening ignored without preferred answers", scoreComponents{SkillMatch: 100, ScreeningScore: 0}, ScoringWeights{SkillMatch: 1, Screening: 1}, 100},
+		{"screening counted with preferred answers", scoreComponents{SkillMatch: 100, ScreeningScore: 0, HasScreening: true}, ScoringWeights{SkillMatch: 1, Screening: 1}, 50},
+		{"zero weights", perfect, ScoringWeights{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combineScore(tt.components, tt.weights); !approxEqual(got, tt.want) {
+				t.Errorf("combineScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
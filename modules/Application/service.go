@@ -38,6 +38,31 @@ func getOrganizationIDForAdmin(db *gorm.DB, userID uint, role string) (uint, err
 	return userOrgID, nil
 }
 
+// canManageApplication reports whether the caller may screen, score or change the status of an application.
+// Super-admins, the partner who owns the project and admins of the project's university qualify.
+// The application must be loaded with its Project.
+func canManageApplication(db *gorm.DB, application Application, userID uint, role string) bool {
+	if role == "super-admin" {
+		return true
+	} else if role == "partner" {
+		return application.Project.UserID == userID
+	} else if role == "university-admin" || role == "delegated-admin" {
+		userOrgID, err := getOrganizationIDForAdmin(db, userID, role)
+		if err != nil {
+			return false
+		}
+		var deptOrgID uint
+		if err := db.Table("departments").
+			Where("id = ?", application.Project.DepartmentID).
+			Select("organization_id").
+			Scan(&deptOrgID).Error; err != nil {
+			return false
+		}
+		return deptOrgID == userOrgID
+	}
+	return false
+}
+
 // GetAll retrieves all applications with optional filters
 func GetAll(c *fiber.Ctx, db *gorm.DB) error {
 	var applications []Application
@@ -54,14 +79,13 @@ func GetAll(c *fiber.Ctx, db *gorm.DB) error {
 	}
 
 	// Role-based filtering:
-	// - Students: Only see their own applications (user ID in student_ids)
+	// - Students: Only see their own applications (member rows in application_members)
 	// - Partners: See all applications for their projects
 	// - University admins: See all applications for projects in their university
 	// - Super-admins: See all applications
 	if role == "student" {
-		// Students can only see their own applications
-		// Use PostgreSQL JSONB contains operator (@>) to check if array contains userID
-		query = query.Where("CAST(student_ids AS jsonb) @> ?::jsonb", fmt.Sprintf(`[%d]`, userID))
+		// Students can only see applications they are a member of
+		query = query.Where("applications.id IN (?)", memberApplicationIDs(db, userID))
 	} else if role == "partner" {
 		// Partners can see all applications for their projects
 		// Join with projects table and filter by user_id (project owner)
@@ -83,13 +107,17 @@ func GetAll(c *fiber.Ctx, db *gorm.DB) error {
 		// Super-admins can see all applications (no additional filter)
 	} else {
 		// For other roles or unknown roles, default to student behavior (own applications only)
-		query = query.Where("CAST(student_ids AS jsonb) @> ?::jsonb", fmt.Sprintf(`[%d]`, userID))
+		query = query.Where("applications.id IN (?)", memberApplicationIDs(db, userID))
 	}
 
 	if err := query.Preload("Project").Preload("Group").Find(&applications).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get applications: " + err.Error()})
 	}
 
+	if err := prepareForViewer(db, applications, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
+
 	return c.JSON(fiber.Map{"data": applications})
 }
 
@@ -105,7 +133,13 @@ func GetByID(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get application: " + err.Error()})
 	}
 
-	return c.JSON(fiber.Map{"data": application})
+	role, _ := c.Locals("role").(string)
+	applications := []Application{application}
+	if err := prepareForViewer(db, applications, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": applications[0]})
 }
 
 // Create creates a new application
@@ -131,48 +165,37 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to validate project"})
 	}
 
+	// Applications are only accepted until the project's deadline
+	if proj.Status == project.StatusClosed || proj.DeadlinePassed(time.Now()) {
+		return c.Status(400).JSON(fiber.Map{"msg": "this project is closed for applications"})
+	}
+
+	// Answers to the project's screening questions are validated and stored in question order
+	var answers []ScreeningAnswer
+	if len(application.Answers) > 0 {
+		if err := json.Unmarshal(application.Answers, &answers); err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "invalid answers: " + err.Error()})
+		}
+	}
+	answersJSON, err := validateAnswers(proj.GetScreeningQuestions(), answers)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+	}
+	application.Answers = answersJSON
+
 	// If applicant type is INDIVIDUAL, ensure student_ids contains the current user
+	var studentIDs []uint
+	var student user.User
 	if application.ApplicantType == "INDIVIDUAL" || application.ApplicantType == "" {
 		application.ApplicantType = "INDIVIDUAL"
-		studentIDs := []uint{userID}
+		studentIDs = []uint{userID}
 		studentIDsJSON, _ := json.Marshal(studentIDs)
 		application.StudentIDs = datatypes.JSON(studentIDsJSON)
 
-		// Create a default group for individual applications
-		// This ensures the application has a group from the start for chat and other group-related features
-		var student user.User
+		// Individual applications get a default group, created with the application below
 		if err := db.First(&student, userID).Error; err != nil {
 			return c.Status(400).JSON(fiber.Map{"msg": "student not found"})
 		}
-
-		// Create a group for the individual application
-		groupName := fmt.Sprintf("%s - %s", student.Name, proj.Title)
-		if len(groupName) > 100 {
-			groupName = groupName[:100] // Truncate if too long
-		}
-
-		group := user.Group{
-			UserID:   userID, // Student is the leader
-			Name:     groupName,
-			Capacity: 1,
-		}
-
-		if err := db.Create(&group).Error; err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "failed to create default group for individual application: " + err.Error()})
-		}
-
-		// Add the student as a member
-		var members []user.User
-		members = append(members, student)
-
-		if len(members) > 0 {
-			if err := db.Model(&group).Association("Members").Append(members); err != nil {
-				return c.Status(400).JSON(fiber.Map{"msg": "failed to add member to group: " + err.Error()})
-			}
-		}
-
-		// Link the group to the application
-		application.GroupID = &group.ID
 	} else if application.ApplicantType == "GROUP" {
 		// Validate group exists and user is a member
 		if application.GroupID == nil {
@@ -183,25 +206,80 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 			return c.Status(404).JSON(fiber.Map{"msg": "group not found"})
 		}
 		// Extract member IDs
-		var memberIDs []uint
 		for _, member := range group.Members {
-			memberIDs = append(memberIDs, member.ID)
+			studentIDs = append(studentIDs, member.ID)
 		}
-		studentIDsJSON, _ := json.Marshal(memberIDs)
+		studentIDsJSON, _ := json.Marshal(studentIDs)
 		application.StudentIDs = datatypes.JSON(studentIDsJSON)
 	}
 
-	// Set default status
-	if application.Status == "" {
-		application.Status = "SUBMITTED"
-	}
+	// New applications always start as SUBMITTED; later moves go through the transition table
+	application.Status = StatusSubmitted
+	application.Version = 1
+
+	role, _ := c.Locals("role").(string)
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		// Reject duplicate applications and students over their active application limit. The students
+		// stay locked until commit so concurrent submissions cannot both pass the checks.
+		if err := lockStudents(tx, studentIDs); err != nil {
+			return err
+		}
+		if err := checkApplicationConflicts(tx, application.ProjectID, studentIDs, 0); err != nil {
+			return err
+		}
+
+		if application.ApplicantType == "INDIVIDUAL" {
+			// Create a default group for individual applications
+			// This ensures the application has a group from the start for chat and other group-related features
+			groupName := fmt.Sprintf("%s - %s", student.Name, proj.Title)
+			if len(groupName) > 100 {
+				groupName = groupName[:100] // Truncate if too long
+			}
+
+			group := user.Group{
+				UserID:   userID, // Student is the leader
+				Name:     groupName,
+				Capacity: 1,
+			}
+			if err := tx.Create(&group).Error; err != nil {
+				return fmt.Errorf("failed to create default group for individual application: %w", err)
+			}
+			if err := tx.Model(&group).Association("Members").Append([]user.User{student}); err != nil {
+				return fmt.Errorf("failed to add member to group: %w", err)
+			}
+
+			// Link the group to the application
+			application.GroupID = &group.ID
+		}
 
-	if err := db.Create(&application).Error; err != nil {
+		if err := tx.Create(&application).Error; err != nil {
+			return err
+		}
+		if err := SyncMembers(tx, &application); err != nil {
+			return err
+		}
+		if err := snapshotRevision(tx, &application, userID); err != nil {
+			return err
+		}
+		return recordSubmission(tx, &application, userID, role)
+	}); err != nil {
+		if isApplicationConflict(err) {
+			return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+		}
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to create application: " + err.Error()})
 	}
+	if application.ApplicantType == "INDIVIDUAL" && application.GroupID != nil {
+		user.MembershipChanged(*application.GroupID, []uint{userID}, nil)
+	}
+
+	// Compute the automatic score from skills and portfolio history
+	application.Project = proj
+	scoreOnCreate(db, &application)
 
 	// Reload with relations
-	db.Preload("Project").Preload("Group").Preload("Group.Members").First(&application, application.ID)
+	if err := reloadForViewer(db, &application, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
 
 	return c.Status(201).JSON(fiber.Map{
 		"msg":  "application created successfully",
@@ -225,42 +303,11 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 
 	// Authorization check for status updates and scoring
 	// Only university admins, partners (project owners), and super-admins can update application status/score
-	canUpdate := false
-	if role == "super-admin" {
-		canUpdate = true
-	} else if role == "partner" {
-		// Partners can only update applications for their own projects
-		if application.Project.UserID == userID {
-			canUpdate = true
-		}
-	} else if role == "university-admin" || role == "delegated-admin" {
-		// University admins and delegated admins can update applications for projects in their university
-		// Get user's organization ID
-		userOrgID, err := getOrganizationIDForAdmin(db, userID, role)
-		if err == nil {
-			// Get project's department to check organization
-			var deptOrgID uint
-			if err := db.Table("departments").
-				Where("id = ?", application.Project.DepartmentID).
-				Select("organization_id").
-				Scan(&deptOrgID).Error; err == nil {
-				if deptOrgID == userOrgID {
-					canUpdate = true
-				}
-			}
-		}
-	} else if role == "student" {
+	canManage := canManageApplication(db, application, userID, role)
+	canUpdate := canManage
+	if role == "student" {
 		// Students can only update their own applications (for withdrawal, etc.)
-		// Check if user ID is in student_ids
-		var studentIDs []uint
-		if err := json.Unmarshal(application.StudentIDs, &studentIDs); err == nil {
-			for _, sid := range studentIDs {
-				if sid == userID {
-					canUpdate = true
-					break
-				}
-			}
-		}
+		canUpdate = isApplicationMember(db, application.ID, userID)
 	}
 
 	// Parse update data - handle both direct Application struct and partial updates
@@ -269,31 +316,45 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "invalid update data: " + err.Error()})
 	}
 
-	// Check if trying to update status or score without permission
-	if (updateData["status"] != nil || updateData["score"] != nil) && !canUpdate {
+	// Check if trying to update status or score without permission. Students never score their own applications.
+	if (updateData["status"] != nil && !canUpdate) || (updateData["score"] != nil && !canManage) {
 		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to update application status or score"})
 	}
+	// Content edits are versioned under the editor, so only the applicants and the project's managers may make them
+	if (updateData["statement"] != nil || updateData["attachments"] != nil) && !canUpdate {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to edit this application"})
+	}
 
-	// Validate and update status if provided
-	if statusVal, ok := updateData["status"].(string); ok {
-		validStatuses := map[string]bool{
-			"SUBMITTED": true, "SHORTLISTED": true, "WAITLIST": true,
-			"REJECTED": true, "OFFERED": true, "ACCEPTED": true,
-			"DECLINED": true, "ASSIGNED": true,
-		}
-		if !validStatuses[statusVal] {
+	// Validate the requested status change; it is applied through the transition table below
+	newStatus := ""
+	if statusVal, ok := updateData["status"].(string); ok && statusVal != application.Status {
+		if !IsValidStatus(statusVal) {
 			return c.Status(400).JSON(fiber.Map{"msg": "invalid status value"})
 		}
-		application.Status = statusVal
+		if restrictedStatuses[statusVal] {
+			return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("status %s can only be set through its dedicated endpoint", statusVal)})
+		}
+		if !CanTransition(application.Status, statusVal, role) {
+			return c.Status(400).JSON(fiber.Map{"msg": transitionError(application.Status, statusVal, role).Error()})
+		}
+		newStatus = statusVal
 	}
+	reason, _ := updateData["reason"].(string)
+
+	// Keep the current content so edits can be versioned
+	previous := application
 
-	// Update score if provided (handle as JSON)
+	// Update score if provided, keeping the caller's manual fields but recomputing the automatic
+	// components server-side as ScoreApplication does
 	if scoreVal, ok := updateData["score"]; ok {
 		scoreJSON, err := json.Marshal(scoreVal)
 		if err != nil {
 			return c.Status(400).JSON(fiber.Map{"msg": "invalid score data: " + err.Error()})
 		}
 		application.Score = datatypes.JSON(scoreJSON)
+		if err := applyAutoScore(db, &application); err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "failed to compute application score: " + err.Error()})
+		}
 	}
 
 	// Update other fields if provided
@@ -306,30 +367,30 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 			application.Attachments = datatypes.JSON(attachmentsJSON)
 		}
 	}
-	// Handle offerExpiresAt update
-	if offerExpiresAtVal, ok := updateData["offerExpiresAt"].(string); ok && offerExpiresAtVal != "" {
-		expiryDate, err := time.Parse("2006-01-02T15:04:05.000Z", offerExpiresAtVal)
-		if err != nil {
-			// Try alternative format
-			expiryDate, err = time.Parse("2006-01-02", offerExpiresAtVal)
-			if err != nil {
-				return c.Status(400).JSON(fiber.Map{"msg": "invalid offerExpiresAt format"})
-			}
-		}
-		expiryDateVal := datatypes.Date(expiryDate)
-		application.OfferExpiresAt = &expiryDateVal
-	}
+	// offerExpiresAt is not editable here: makeOffer sets it and the offer sweeper enforces it
 
 	// Update updatedAt timestamp
 	application.UpdatedAt = time.Now()
 
-	// Save updates
-	if err := db.Save(&application).Error; err != nil {
-		return c.Status(400).JSON(fiber.Map{"msg": "failed to update application: " + err.Error()})
+	// Save updates and record the edit and status change, if any, in one transaction
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if contentChanged(application, previous) {
+			if err := recordEdit(tx, &application, previous, userID); err != nil {
+				return err
+			}
+		}
+		if newStatus != "" {
+			return changeStatus(tx, &application, newStatus, userID, role, reason)
+		}
+		return tx.Save(&application).Error
+	}); err != nil {
+		return c.Status(transitionStatus(err)).JSON(fiber.Map{"msg": "failed to update application: " + err.Error()})
 	}
 
 	// Reload with relations
-	db.Preload("Project").Preload("Group").First(&application, application.ID)
+	if err := reloadForViewer(db, &application, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
 
 	return c.JSON(fiber.Map{
 		"msg":  "application updated successfully",
@@ -352,29 +413,7 @@ func ScoreApplication(c *fiber.Ctx, db *gorm.DB) error {
 	}
 
 	// Authorization: Only university admins, partners, and super-admins can score
-	canScore := false
-	if role == "super-admin" {
-		canScore = true
-	} else if role == "partner" {
-		if application.Project.UserID == userID {
-			canScore = true
-		}
-	} else if role == "university-admin" || role == "delegated-admin" {
-		userOrgID, err := getOrganizationIDForAdmin(db, userID, role)
-		if err == nil {
-			var deptOrgID uint
-			if err := db.Table("departments").
-				Where("id = ?", application.Project.DepartmentID).
-				Select("organization_id").
-				Scan(&deptOrgID).Error; err == nil {
-				if deptOrgID == userOrgID {
-					canScore = true
-				}
-			}
-		}
-	}
-
-	if !canScore {
+	if !canManageApplication(db, application, userID, role) {
 		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to score this application"})
 	}
 
@@ -393,14 +432,20 @@ func ScoreApplication(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "invalid score format: " + err.Error()})
 	}
 
+	// Keep the caller's manual fields but always recompute the automatic components server-side
 	application.Score = datatypes.JSON(scoreJSON)
+	if err := applyAutoScore(db, &application); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to compute application score: " + err.Error()})
+	}
 	application.UpdatedAt = time.Now()
 
 	if err := db.Save(&application).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to update application score: " + err.Error()})
 	}
 
-	db.Preload("Project").Preload("Group").First(&application, application.ID)
+	if err := reloadForViewer(db, &application, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
 
 	return c.JSON(fiber.Map{
 		"msg":  "application scored successfully",
@@ -438,112 +483,34 @@ func updateApplicationStatus(c *fiber.Ctx, db *gorm.DB, newStatus string) error
 	}
 
 	// Authorization check
-	canUpdate := false
-	if role == "super-admin" {
-		canUpdate = true
-	} else if role == "partner" {
-		if application.Project.UserID == userID {
-			canUpdate = true
-		}
-	} else if role == "university-admin" || role == "delegated-admin" {
-		userOrgID, err := getOrganizationIDForAdmin(db, userID, role)
-		if err == nil {
-			var deptOrgID uint
-			if err := db.Table("departments").
-				Where("id = ?", application.Project.DepartmentID).
-				Select("organization_id").
-				Scan(&deptOrgID).Error; err == nil {
-				if deptOrgID == userOrgID {
-					canUpdate = true
-				}
-			}
-		}
-	}
-
-	if !canUpdate {
+	if !canManageApplication(db, application, userID, role) {
 		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to update this application status"})
 	}
 
-	// Validate status
-	validStatuses := map[string]bool{
-		"SUBMITTED": true, "SHORTLISTED": true, "WAITLIST": true,
-		"REJECTED": true, "OFFERED": true, "ACCEPTED": true,
-		"DECLINED": true, "ASSIGNED": true,
-	}
-	if !validStatuses[newStatus] {
+	// Validate status against the transition table
+	if !IsValidStatus(newStatus) {
 		return c.Status(400).JSON(fiber.Map{"msg": "invalid status value"})
 	}
-
-	// If setting status to ASSIGNED, ensure application has a group for chat functionality
-	if newStatus == "ASSIGNED" && application.GroupID == nil {
-		// Extract student IDs from the application
-		var studentIDs []uint
-		if err := json.Unmarshal(application.StudentIDs, &studentIDs); err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "failed to parse student IDs"})
-		}
-
-		if len(studentIDs) == 0 {
-			return c.Status(400).JSON(fiber.Map{"msg": "application must have at least one student"})
-		}
-
-		// Get the first student (leader)
-		var student user.User
-		if err := db.First(&student, studentIDs[0]).Error; err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "student not found"})
-		}
-
-		// Create a group for the application
-		groupName := fmt.Sprintf("%s - %s", student.Name, application.Project.Title)
-		if len(groupName) > 100 {
-			groupName = groupName[:100] // Truncate if too long
-		}
-
-		group := user.Group{
-			UserID:   studentIDs[0], // First student is the leader
-			Name:     groupName,
-			Capacity: len(studentIDs),
-		}
-
-		if err := db.Create(&group).Error; err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "failed to create group for application: " + err.Error()})
-		}
-
-		// Add all students as members
-		var members []user.User
-		for _, studentID := range studentIDs {
-			var member user.User
-			if err := db.First(&member, studentID).Error; err == nil {
-				members = append(members, member)
-			}
-		}
-
-		if len(members) > 0 {
-			if err := db.Model(&group).Association("Members").Append(members); err != nil {
-				return c.Status(400).JSON(fiber.Map{"msg": "failed to add members to group: " + err.Error()})
-			}
-		}
-
-		// Link the group to the application
-		application.GroupID = &group.ID
-	} else if newStatus == "ASSIGNED" && application.GroupID != nil {
-		// Verify the group still exists
-		var groupCount int64
-		if err := db.Model(&user.Group{}).Where("id = ?", application.GroupID).Count(&groupCount).Error; err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "failed to validate group"})
-		}
-		if groupCount == 0 {
-			return c.Status(404).JSON(fiber.Map{"msg": "group not found. Please reassign the application."})
-		}
+	if !CanTransition(application.Status, newStatus, role) {
+		return c.Status(400).JSON(fiber.Map{"msg": transitionError(application.Status, newStatus, role).Error()})
 	}
 
-	application.Status = newStatus
-	application.UpdatedAt = time.Now()
+	// Optional reason for the status change
+	type StatusRequest struct {
+		Reason string `json:"reason"`
+	}
+	var req StatusRequest
+	c.BodyParser(&req) // Ignore error if body is empty
 
-	if err := db.Save(&application).Error; err != nil {
-		return c.Status(400).JSON(fiber.Map{"msg": "failed to update application status: " + err.Error()})
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return changeStatus(tx, &application, newStatus, userID, role, req.Reason)
+	}); err != nil {
+		return c.Status(transitionStatus(err)).JSON(fiber.Map{"msg": "failed to update application status: " + err.Error()})
 	}
 
-	db.Preload("Project").Preload("Group").Preload("Group.Members").First(&application, application.ID)
+	if err := reloadForViewer(db, &application, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
 
 	return c.JSON(fiber.Map{
 		"msg":  fmt.Sprintf("application %s successfully", newStatus),
@@ -552,6 +519,7 @@ func updateApplicationStatus(c *fiber.Ctx, db *gorm.DB, newStatus string) error
 }
 
 // OfferApplication issues an offer to an application (university admin action)
+// The students have until OfferExpiresAt to accept; the application is only assigned once they do.
 func OfferApplication(c *fiber.Ctx, db *gorm.DB) error {
 	id := c.Params("id")
 	var application Application
@@ -566,184 +534,47 @@ func OfferApplication(c *fiber.Ctx, db *gorm.DB) error {
 	}
 
 	// Authorization: Only university admins, partners, and super-admins can offer
-	canOffer := false
-	if role == "super-admin" {
-		canOffer = true
-	} else if role == "partner" {
-		if application.Project.UserID == userID {
-			canOffer = true
-		}
-	} else if role == "university-admin" || role == "delegated-admin" {
-		userOrgID, err := getOrganizationIDForAdmin(db, userID, role)
-		if err == nil {
-			var deptOrgID uint
-			if err := db.Table("departments").
-				Where("id = ?", application.Project.DepartmentID).
-				Select("organization_id").
-				Scan(&deptOrgID).Error; err == nil {
-				if deptOrgID == userOrgID {
-					canOffer = true
-				}
-			}
-		}
-	}
-
-	if !canOffer {
+	if !canManageApplication(db, application, userID, role) {
 		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to offer this application"})
 	}
 
 	// Validate that application is in SHORTLISTED status (only shortlisted applications can receive offers)
-	if application.Status != "SHORTLISTED" {
+	if application.Status != StatusShortlisted {
 		return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("cannot offer application with status %s. Application must be SHORTLISTED to receive an offer", application.Status)})
 	}
 
-	// Check if project already has an assigned application
-	var existingAssignedApplication Application
-	if err := db.Where("project_id = ? AND status = ?", application.ProjectID, "ASSIGNED").First(&existingAssignedApplication).Error; err == nil {
-		return c.Status(400).JSON(fiber.Map{"msg": "this project already has an assigned group"})
-	}
-
-	// Ensure all assigned applications have a group for chat functionality
-	// Chat requires a group, so we create one if it doesn't exist
-	if application.GroupID == nil {
-		// Extract student IDs from the application
-		var studentIDs []uint
-		if err := json.Unmarshal(application.StudentIDs, &studentIDs); err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "failed to parse student IDs"})
-		}
-
-		if len(studentIDs) == 0 {
-			return c.Status(400).JSON(fiber.Map{"msg": "application must have at least one student"})
-		}
-
-		// Get the first student (leader for individual, or first member for group)
-		var student user.User
-		if err := db.First(&student, studentIDs[0]).Error; err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "student not found"})
-		}
-
-		// Create a group for the application
-		// Group name will be based on the student's name and project
-		var groupName string
-		if application.ApplicantType == "GROUP" {
-			// For group applications, try to use existing group name if available
-			groupName = fmt.Sprintf("%s - %s", student.Name, application.Project.Title)
-		} else {
-			// For individual applications, use student name and project
-			groupName = fmt.Sprintf("%s - %s", student.Name, application.Project.Title)
-		}
-
-		if len(groupName) > 100 {
-			groupName = groupName[:100] // Truncate if too long
-		}
-
-		group := user.Group{
-			UserID:   studentIDs[0], // First student is the leader
-			Name:     groupName,
-			Capacity: len(studentIDs),
-		}
-
-		if err := db.Create(&group).Error; err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "failed to create group for application: " + err.Error()})
-		}
-
-		// Add all students as members
-		var members []user.User
-		for _, studentID := range studentIDs {
-			var member user.User
-			if err := db.First(&member, studentID).Error; err == nil {
-				members = append(members, member)
-			}
+	// Reserve the team's seats; the project row is locked so concurrent offers cannot over-assign it
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		ok, err := hasSeatFor(tx, application)
+		if err != nil {
+			return err
 		}
-
-		if len(members) > 0 {
-			if err := db.Model(&group).Association("Members").Append(members); err != nil {
-				return c.Status(400).JSON(fiber.Map{"msg": "failed to add members to group: " + err.Error()})
-			}
+		if !ok {
+			return errNoSeats
 		}
-
-		// Link the group to the application
-		application.GroupID = &group.ID
-	} else {
-		// For GROUP applications, verify the group still exists
-		var existingGroup user.Group
-		if err := db.First(&existingGroup, application.GroupID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// Group was deleted, create a new one
-				// Extract student IDs from the application
-				var studentIDs []uint
-				if err := json.Unmarshal(application.StudentIDs, &studentIDs); err != nil {
-					return c.Status(400).JSON(fiber.Map{"msg": "failed to parse student IDs"})
-				}
-
-				if len(studentIDs) == 0 {
-					return c.Status(400).JSON(fiber.Map{"msg": "application must have at least one student"})
-				}
-
-				// Get the first student (leader)
-				var student user.User
-				if err := db.First(&student, studentIDs[0]).Error; err != nil {
-					return c.Status(400).JSON(fiber.Map{"msg": "student not found"})
-				}
-
-				// Create a new group for the application
-				groupName := fmt.Sprintf("%s - %s", student.Name, application.Project.Title)
-				if len(groupName) > 100 {
-					groupName = groupName[:100] // Truncate if too long
-				}
-
-				group := user.Group{
-					UserID:   studentIDs[0], // First student is the leader
-					Name:     groupName,
-					Capacity: len(studentIDs),
-				}
-
-				if err := db.Create(&group).Error; err != nil {
-					return c.Status(400).JSON(fiber.Map{"msg": "failed to create group for application: " + err.Error()})
-				}
-
-				// Add all students as members
-				var members []user.User
-				for _, studentID := range studentIDs {
-					var member user.User
-					if err := db.First(&member, studentID).Error; err == nil {
-						members = append(members, member)
-					}
-				}
-
-				if len(members) > 0 {
-					if err := db.Model(&group).Association("Members").Append(members); err != nil {
-						return c.Status(400).JSON(fiber.Map{"msg": "failed to add members to group: " + err.Error()})
-					}
-				}
-
-				// Link the new group to the application
-				application.GroupID = &group.ID
-			} else {
-				return c.Status(400).JSON(fiber.Map{"msg": "failed to validate group"})
-			}
+		return makeOffer(tx, &application, userID, role, "")
+	}); err != nil {
+		if err == errNoSeats {
+			return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
 		}
-		// Group exists, continue
+		return c.Status(transitionStatus(err)).JSON(fiber.Map{"msg": "failed to offer application: " + err.Error()})
 	}
 
-	// Update application status to ASSIGNED (immediate assignment, no offer/accept flow)
-	application.Status = "ASSIGNED"
-	application.UpdatedAt = time.Now()
-
-	if err := db.Save(&application).Error; err != nil {
-		return c.Status(400).JSON(fiber.Map{"msg": "failed to assign application: " + err.Error()})
-	}
+	notifyOffer(db, application)
 
 	// Reload with relations
-	db.Preload("Project").Preload("Group").Preload("Group.Members").First(&application, application.ID)
+	if err := reloadForViewer(db, &application, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
 
 	return c.JSON(fiber.Map{
-		"msg":  "group assigned to project successfully",
+		"msg":  "offer sent successfully",
 		"data": application,
 	})
 }
 
 // AcceptOffer accepts an offer (student action)
+// Accepting assigns the application to the project and sets up its chat group.
 func AcceptOffer(c *fiber.Ctx, db *gorm.DB) error {
 	id := c.Params("id")
 	var application Application
@@ -757,113 +588,44 @@ func AcceptOffer(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to find application"})
 	}
 
-	// Only students can accept offers (or partners/university admins on their behalf)
+	// Only students can accept offers (or the project's partner and admins on their behalf)
 	if role == "student" {
 		// Verify the student is part of this application
-		var studentIDs []uint
-		if err := json.Unmarshal(application.StudentIDs, &studentIDs); err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "invalid application data"})
-		}
-
-		isApplicant := false
-		for _, sid := range studentIDs {
-			if sid == userID {
-				isApplicant = true
-				break
-			}
-		}
-
-		if !isApplicant {
+		if !isApplicationMember(db, application.ID, userID) {
 			return c.Status(403).JSON(fiber.Map{"msg": "you can only accept offers for your own applications"})
 		}
-	} else if role != "super-admin" && role != "university-admin" && role != "delegated-admin" && role != "partner" {
+	} else if !canManageApplication(db, application, userID, role) {
 		return c.Status(403).JSON(fiber.Map{"msg": "only students can accept offers"})
 	}
 
 	// Validate that application has an active offer
-	if application.Status != "OFFERED" {
+	if application.Status != StatusOffered {
 		return c.Status(400).JSON(fiber.Map{"msg": "application does not have an active offer"})
 	}
 
 	// Check if offer has expired
-	if application.OfferExpiresAt != nil {
-		expiryTime := time.Time(*application.OfferExpiresAt)
-		if time.Now().After(expiryTime) {
-			return c.Status(400).JSON(fiber.Map{"msg": "this offer has expired"})
-		}
+	if application.OfferExpiresAt != nil && time.Now().After(*application.OfferExpiresAt) {
+		return c.Status(400).JSON(fiber.Map{"msg": "this offer has expired"})
 	}
 
-	// Ensure application has a group for chat functionality before assigning
-	if application.GroupID == nil {
-		// Extract student IDs from the application
-		var studentIDs []uint
-		if err := json.Unmarshal(application.StudentIDs, &studentIDs); err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "failed to parse student IDs"})
-		}
-
-		if len(studentIDs) == 0 {
-			return c.Status(400).JSON(fiber.Map{"msg": "application must have at least one student"})
-		}
-
-		// Get the first student (leader)
-		var student user.User
-		if err := db.First(&student, studentIDs[0]).Error; err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "student not found"})
-		}
-
-		// Create a group for the application
-		groupName := fmt.Sprintf("%s - %s", student.Name, application.Project.Title)
-		if len(groupName) > 100 {
-			groupName = groupName[:100] // Truncate if too long
-		}
-
-		group := user.Group{
-			UserID:   studentIDs[0], // First student is the leader
-			Name:     groupName,
-			Capacity: len(studentIDs),
-		}
-
-		if err := db.Create(&group).Error; err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "failed to create group for application: " + err.Error()})
-		}
-
-		// Add all students as members
-		var members []user.User
-		for _, studentID := range studentIDs {
-			var member user.User
-			if err := db.First(&member, studentID).Error; err == nil {
-				members = append(members, member)
-			}
-		}
-
-		if len(members) > 0 {
-			if err := db.Model(&group).Association("Members").Append(members); err != nil {
-				return c.Status(400).JSON(fiber.Map{"msg": "failed to add members to group: " + err.Error()})
-			}
-		}
-
-		// Link the group to the application
-		application.GroupID = &group.ID
-	} else {
-		// Verify the group still exists
-		var groupCount int64
-		if err := db.Model(&user.Group{}).Where("id = ?", application.GroupID).Count(&groupCount).Error; err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "failed to validate group"})
-		}
-		if groupCount == 0 {
-			return c.Status(404).JSON(fiber.Map{"msg": "group not found. Please contact support."})
+	// Ensure application has a group for chat functionality, then assign it (student accepted the offer)
+	var joined []uint
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if joined, err = ensureApplicationGroup(tx, &application); err != nil {
+			return err
 		}
+		return changeStatus(tx, &application, StatusAssigned, userID, role, "offer accepted")
+	}); err != nil {
+		return c.Status(transitionStatus(err)).JSON(fiber.Map{"msg": "failed to accept offer: " + err.Error()})
 	}
-
-	// Update status to ASSIGNED (student accepted the offer)
-	application.Status = "ASSIGNED"
-	application.UpdatedAt = time.Now()
-
-	if err := db.Save(&application).Error; err != nil {
-		return c.Status(400).JSON(fiber.Map{"msg": "failed to accept offer: " + err.Error()})
+	if len(joined) > 0 {
+		user.MembershipChanged(*application.GroupID, joined, nil)
 	}
 
-	db.Preload("Project").Preload("Group").Preload("Group.Members").First(&application, application.ID)
+	if err := reloadForViewer(db, &application, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
 
 	return c.JSON(fiber.Map{
 		"msg":  "offer accepted successfully",
@@ -872,6 +634,7 @@ func AcceptOffer(c *fiber.Ctx, db *gorm.DB) error {
 }
 
 // DeclineOffer declines an offer (student action)
+// The freed seats are offered to the next applications on the project's waitlist.
 func DeclineOffer(c *fiber.Ctx, db *gorm.DB) error {
 	id := c.Params("id")
 	var application Application
@@ -888,20 +651,7 @@ func DeclineOffer(c *fiber.Ctx, db *gorm.DB) error {
 	// Only students can decline offers
 	if role == "student" {
 		// Verify the student is part of this application
-		var studentIDs []uint
-		if err := json.Unmarshal(application.StudentIDs, &studentIDs); err != nil {
-			return c.Status(400).JSON(fiber.Map{"msg": "invalid application data"})
-		}
-
-		isApplicant := false
-		for _, sid := range studentIDs {
-			if sid == userID {
-				isApplicant = true
-				break
-			}
-		}
-
-		if !isApplicant {
+		if !isApplicationMember(db, application.ID, userID) {
 			return c.Status(403).JSON(fiber.Map{"msg": "you can only decline offers for your own applications"})
 		}
 	} else if role != "super-admin" {
@@ -909,19 +659,37 @@ func DeclineOffer(c *fiber.Ctx, db *gorm.DB) error {
 	}
 
 	// Validate that application has an offer
-	if application.Status != "OFFERED" {
+	if application.Status != StatusOffered {
 		return c.Status(400).JSON(fiber.Map{"msg": "application does not have an active offer"})
 	}
 
+	// Optional reason for declining
+	type DeclineRequest struct {
+		Reason string `json:"reason"`
+	}
+	var req DeclineRequest
+	c.BodyParser(&req) // Ignore error if body is empty
+
 	// Update status to DECLINED (student declined the offer)
-	application.Status = "DECLINED"
-	application.UpdatedAt = time.Now()
+	var promoted []Application
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := changeStatus(tx, &application, StatusDeclined, userID, role, req.Reason); err != nil {
+			return err
+		}
+		var err error
+		promoted, err = promoteWaitlisted(tx, application.ProjectID)
+		return err
+	}); err != nil {
+		return c.Status(transitionStatus(err)).JSON(fiber.Map{"msg": "failed to decline offer: " + err.Error()})
+	}
 
-	if err := db.Save(&application).Error; err != nil {
-		return c.Status(400).JSON(fiber.Map{"msg": "failed to decline offer: " + err.Error()})
+	for _, app := range promoted {
+		notifyOffer(db, app)
 	}
 
-	db.Preload("Project").Preload("Group").First(&application, application.ID)
+	if err := reloadForViewer(db, &application, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
 
 	return c.JSON(fiber.Map{
 		"msg":  "offer declined successfully",
@@ -929,6 +697,38 @@ func DeclineOffer(c *fiber.Ctx, db *gorm.DB) error {
 	})
 }
 
+// GetHistory returns the status change history of an application (partner and university admin view)
+func GetHistory(c *fiber.Ctx, db *gorm.DB) error {
+	id := c.Params("id")
+	var application Application
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	if err := db.Preload("Project").First(&application, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "application not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to find application"})
+	}
+
+	if !canManageApplication(db, application, userID, role) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to view this application's history"})
+	}
+
+	var events []ApplicationStatusEvent
+	if err := db.Where("application_id = ?", application.ID).
+		Preload("Actor").
+		Order("created_at ASC").
+		Find(&events).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get application history: " + err.Error()})
+	}
+	if err := redactHistory(db, application, events, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get application history: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": events})
+}
+
 // Delete deletes an application
 func Delete(c *fiber.Ctx, db *gorm.DB) error {
 	id := c.Params("id")
@@ -941,6 +741,19 @@ func Delete(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to find application"})
 	}
 
+	// Students may only delete their own applications before review starts; after that the history
+	// matters to reviewers, so they withdraw instead
+	role, _ := c.Locals("role").(string)
+	if role == "student" {
+		userID := c.Locals("user_id").(uint)
+		if !isApplicationMember(db, application.ID, userID) {
+			return c.Status(403).JSON(fiber.Map{"msg": "you can only delete your own applications"})
+		}
+		if application.Status != StatusSubmitted {
+			return c.Status(400).JSON(fiber.Map{"msg": "applications under review must be withdrawn instead of deleted"})
+		}
+	}
+
 	if err := db.Delete(&application).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to delete application: " + err.Error()})
 	}
@@ -961,7 +774,7 @@ func UploadFiles(c *fiber.Ctx, db *gorm.DB) error {
 	}
 
 	// Create uploads directory if it doesn't exist
-	uploadDir := "uploads/applications"
+	uploadDir := applicationUploadDir
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
 		return c.Status(500).JSON(fiber.Map{"msg": "failed to create upload directory"})
 	}
@@ -969,7 +782,7 @@ func UploadFiles(c *fiber.Ctx, db *gorm.DB) error {
 	var paths []string
 	for _, file := range files {
 		// Generate unique filename
-		filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), file.Filename)
+		filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
 		filePath := filepath.Join(uploadDir, filename)
 
 		if err := c.SaveFile(file, filePath); err != nil {
@@ -0,0 +1,188 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Application statuses
+const (
+	StatusSubmitted   = "SUBMITTED"
+	StatusShortlisted = "SHORTLISTED"
+	StatusWaitlist    = "WAITLIST"
+	StatusRejected    = "REJECTED"
+	StatusOffered     = "OFFERED"
+	StatusAccepted    = "ACCEPTED"
+	StatusDeclined    = "DECLINED"
+	StatusAssigned    = "ASSIGNED"
+	StatusExpired     = "EXPIRED"
+	StatusWithdrawn   = "WITHDRAWN"
+)
+
+// Roles named in the transition table. System transitions are made by the offer sweeper and waitlist
+// promotion.
+var (
+	studentRoles  = []string{"student"}
+	managerRoles  = []string{"partner", "university-admin", "delegated-admin", "super-admin"}
+	withdrawRoles = []string{"student", "super-admin"}
+	systemRoles   = []string{"system"}
+	offerRoles    = []string{"partner", "university-admin", "delegated-admin", "super-admin", "system"}
+	acceptRoles   = []string{"student", "partner", "university-admin", "delegated-admin", "super-admin"} // Staff may accept on the students' behalf on projects they manage
+)
+
+// transition is a move to another status and the roles allowed to make it
+type transition struct {
+	To    string
+	Roles []string
+}
+
+// statusTransitions lists, for every status, the statuses an application may move to next and who may
+// move it. Statuses with no entry are terminal. Only AcceptOffer moves an application to ASSIGNED.
+// Students may withdraw until they are assigned, answer offers, and resubmit withdrawn or rejected
+// applications; screening decisions belong to the project owner and admins.
+var statusTransitions = map[string][]transition{
+	StatusSubmitted: {
+		{StatusShortlisted, managerRoles}, {StatusWaitlist, managerRoles}, {StatusRejected, managerRoles},
+		{StatusWithdrawn, withdrawRoles},
+	},
+	StatusShortlisted: {
+		{StatusOffered, managerRoles}, {StatusWaitlist, managerRoles}, {StatusRejected, managerRoles},
+		{StatusWithdrawn, withdrawRoles},
+	},
+	StatusWaitlist: {
+		{StatusShortlisted, managerRoles}, {StatusOffered, offerRoles},
+		{StatusRejected, managerRoles}, {StatusWithdrawn, withdrawRoles},
+	},
+	StatusOffered: {
+		{StatusAssigned, acceptRoles},
+		{StatusDeclined, withdrawRoles}, {StatusExpired, systemRoles}, {StatusWithdrawn, withdrawRoles},
+	},
+	StatusAccepted: {
+		{StatusAssigned, acceptRoles},
+		{StatusWithdrawn, withdrawRoles},
+	},
+	StatusRejected:  {{StatusSubmitted, studentRoles}},
+	StatusWithdrawn: {{StatusSubmitted, studentRoles}},
+}
+
+// restrictedStatuses can only be reached through their dedicated endpoints (offers, withdrawal and
+// resubmission) and the offer sweeper, which take care of expiry, notifications and chat groups
+var restrictedStatuses = map[string]bool{
+	StatusSubmitted: true,
+	StatusOffered:   true,
+	StatusAccepted:  true,
+	StatusDeclined:  true,
+	StatusAssigned:  true,
+	StatusExpired:   true,
+	StatusWithdrawn: true,
+}
+
+// IsValidStatus reports whether status is a known application status
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusSubmitted, StatusShortlisted, StatusWaitlist, StatusRejected,
+		StatusOffered, StatusAccepted, StatusDeclined, StatusAssigned, StatusExpired, StatusWithdrawn:
+		return true
+	}
+	return false
+}
+
+// findTransition returns the table entry for a move from one status to another
+func findTransition(from, to string) (transition, bool) {
+	for _, next := range statusTransitions[from] {
+		if next.To == to {
+			return next, true
+		}
+	}
+	return transition{}, false
+}
+
+// CanTransition reports whether a user with the given role may move an application in status from to
+// status to
+func CanTransition(from, to, role string) bool {
+	next, ok := findTransition(from, to)
+	if !ok {
+		return false
+	}
+	for _, allowed := range next.Roles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// errStatusConflict is returned when an application's status changed since it was loaded
+var errStatusConflict = errors.New("the application's status changed in the meantime; reload it and try again")
+
+// transitionError builds the error returned for a move the transition table does not allow
+func transitionError(from, to, role string) error {
+	if _, ok := findTransition(from, to); ok {
+		return fmt.Errorf("a %s cannot move an application from %s to %s", role, from, to)
+	}
+	return fmt.Errorf("cannot move application from %s to %s", from, to)
+}
+
+// transitionStatus maps status change errors to HTTP statuses
+func transitionStatus(err error) int {
+	if errors.Is(err, errStatusConflict) {
+		return 409
+	}
+	return 400
+}
+
+// changeStatus moves the application to newStatus and records the transition.
+// Callers should run it inside a transaction together with any related writes.
+// actorID is 0 for system-driven transitions. The row is only updated if its status is still the one
+// loaded, so concurrent changes (e.g. an accept racing the offer sweeper) cannot both win.
+func changeStatus(tx *gorm.DB, application *Application, newStatus string, actorID uint, actorRole, reason string) error {
+	from := application.Status
+	if !CanTransition(from, newStatus, actorRole) {
+		return transitionError(from, newStatus, actorRole)
+	}
+
+	event := ApplicationStatusEvent{
+		ApplicationID: application.ID,
+		ActorRole:     actorRole,
+		FromStatus:    from,
+		ToStatus:      newStatus,
+		Reason:        reason,
+	}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+
+	application.Status = newStatus
+	application.UpdatedAt = time.Now()
+
+	result := tx.Model(application).
+		Where("status = ?", from).
+		Select("*").
+		Omit("created_at", clause.Associations).
+		Updates(application)
+	if result.Error != nil {
+		application.Status = from
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		application.Status = from
+		return errStatusConflict
+	}
+
+	return tx.Create(&event).Error
+}
+
+// recordSubmission records the initial status of a newly created application
+func recordSubmission(tx *gorm.DB, application *Application, actorID uint, actorRole string) error {
+	event := ApplicationStatusEvent{
+		ApplicationID: application.ID,
+		ActorID:       &actorID,
+		ActorRole:     actorRole,
+		ToStatus:      application.Status,
+	}
+	return tx.Create(&event).Error
+}
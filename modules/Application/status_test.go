@@ -0,0 +1,120 @@
+package application
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to, role string
+		want           bool
+	}{
+		// Screening decisions belong to the project owner and admins
+		{StatusSubmitted, StatusShortlisted, "partner", true},
+		{StatusSubmitted, StatusShortlisted, "university-admin", true},
+		{StatusSubmitted, StatusShortlisted, "student", false},
+		{StatusSubmitted, StatusWaitlist, "student", false},
+		{StatusSubmitted, StatusRejected, "student", false},
+		{StatusSubmitted, StatusRejected, "delegated-admin", true},
+		{StatusShortlisted, StatusOffered, "super-admin", true},
+		{StatusShortlisted, StatusOffered, "student", false},
+		{StatusWaitlist, StatusOffered, "system", true},
+		{StatusSubmitted, StatusShortlisted, "supervisor", false},
+
+		// Students withdraw, answer offers and resubmit
+		{StatusSubmitted, StatusWithdrawn, "student", true},
+		{StatusOffered, StatusWithdrawn, "student", true},
+		{StatusSubmitted, StatusWithdrawn, "partner", false},
+		{StatusOffered, StatusAssigned, "student", true},
+		{StatusOffered, StatusAssigned, "partner", true},
+		{StatusOffered, StatusDeclined, "student", true},
+		{StatusOffered, StatusDeclined, "partner", false},
+		{StatusWithdrawn, StatusSubmitted, "student", true},
+		{StatusRejected, StatusSubmitted, "student", true},
+		{StatusRejected, StatusSubmitted, "partner", false},
+
+		// Only the sweeper expires offers
+		{StatusOffered, StatusExpired, "system", true},
+		{StatusOffered, StatusExpired, "super-admin", false},
+
+		// Moves the table does not list
+		{StatusSubmitted, StatusAssigned, "super-admin", false},
+		{StatusSubmitted, StatusOffered, "partner", false},
+		{StatusAssigned, StatusWithdrawn, "student", false},
+		{StatusExpired, StatusSubmitted, "student", false},
+		{StatusDeclined, StatusOffered, "system", false},
+		{"UNKNOWN", StatusSubmitted, "student", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to+" by "+tt.role, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to, tt.role); got != tt.want {
+				t.Errorf("CanTransition(%s, %s, %s) = %v, want %v", tt.from, tt.to, tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionTableTargetsAreValid(t *testing.T) {
+	for from, transitions := range statusTransitions {
+		if !IsValidStatus(from) {
+			t.Errorf("unknown status %s in the transition table", from)
+		}
+		for _, next := range transitions {
+			if !IsValidStatus(next.To) {
+				t.Errorf("%s lists unknown status %s", from, next.To)
+			}
+			if len(next.Roles) == 0 {
+				t.Errorf("%s -> %s allows no roles", from, next.To)
+			}
+		}
+	}
+}
+
+func TestTransitionError(t *testing.T) {
+	tests := []struct {
+		name, from, to, role string
+		want                 string
+	}{
+		{"role not allowed", StatusSubmitted, StatusShortlisted, "student", "a student cannot move"},
+		{"move not in table", StatusSubmitted, StatusAssigned, "partner", "cannot move application from SUBMITTED to ASSIGNED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := transitionError(tt.from, tt.to, tt.role); !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("transitionError() = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"conflict", errStatusConflict, 409},
+		{"wrapped conflict", errors.Join(errors.New("accept"), errStatusConflict), 409},
+		{"other", errors.New("boom"), 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transitionStatus(tt.err); got != tt.want {
+				t.Errorf("transitionStatus() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeStatusRejectsDisallowedRoleBeforeWriting(t *testing.T) {
+	application := Application{Status: StatusSubmitted}
+	// A nil transaction would panic if changeStatus reached the database
+	if err := changeStatus(nil, &application, StatusShortlisted, 1, "student", ""); err == nil {
+		t.Fatal("changeStatus() allowed a student to shortlist")
+	}
+	if application.Status != StatusSubmitted {
+		t.Errorf("status changed to %s after a rejected transition", application.Status)
+	}
+}
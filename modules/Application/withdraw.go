@@ -0,0 +1,228 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// snapshotRevision stores the current statement, attachments and answers of an application as its
+// revision number application.Version
+func snapshotRevision(tx *gorm.DB, application *Application, editorID uint) error {
+	revision := ApplicationRevision{
+		ApplicationID: application.ID,
+		Version:       application.Version,
+		Statement:     application.Statement,
+		Attachments:   application.Attachments,
+		Answers:       application.Answers,
+	}
+	if editorID != 0 {
+		revision.EditorID = &editorID
+	}
+	return tx.Create(&revision).Error
+}
+
+// recordEdit versions an edit to an application. It must be called after the new content is set on
+// the application and before it is saved; previous holds the content before the edit. Applications
+// that predate revisions get their original content stored as version 1 first.
+func recordEdit(tx *gorm.DB, application *Application, previous Application, editorID uint) error {
+	var count int64
+	if err := tx.Model(&ApplicationRevision{}).Where("application_id = ?", application.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		previous.Version = 1
+		if err := snapshotRevision(tx, &previous, 0); err != nil {
+			return err
+		}
+		application.Version = 1
+	}
+
+	application.Version++
+	return snapshotRevision(tx, application, editorID)
+}
+
+// contentChanged reports whether the statement, attachments or answers differ between two versions
+func contentChanged(a, b Application) bool {
+	return a.Statement != b.Statement || string(a.Attachments) != string(b.Attachments) || string(a.Answers) != string(b.Answers)
+}
+
+// Withdraw lets a student pull an application out of consideration any time before it is assigned.
+// Withdrawing an offer frees its seats for the project's waitlist.
+func Withdraw(c *fiber.Ctx, db *gorm.DB) error {
+	id := c.Params("id")
+	var application Application
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	if err := db.Preload("Project").First(&application, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "application not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to find application"})
+	}
+
+	if role != "super-admin" && !isApplicationMember(db, application.ID, userID) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you can only withdraw your own applications"})
+	}
+
+	if !CanTransition(application.Status, StatusWithdrawn, role) {
+		return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("an application with status %s cannot be withdrawn", application.Status)})
+	}
+
+	// Optional reason for withdrawing
+	type WithdrawRequest struct {
+		Reason string `json:"reason"`
+	}
+	var req WithdrawRequest
+	c.BodyParser(&req) // Ignore error if body is empty
+
+	wasOffered := application.Status == StatusOffered
+	var promoted []Application
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := changeStatus(tx, &application, StatusWithdrawn, userID, role, req.Reason); err != nil {
+			return err
+		}
+		if !wasOffered {
+			return nil
+		}
+		var err error
+		promoted, err = promoteWaitlisted(tx, application.ProjectID)
+		return err
+	}); err != nil {
+		return c.Status(transitionStatus(err)).JSON(fiber.Map{"msg": "failed to withdraw application: " + err.Error()})
+	}
+
+	for _, app := range promoted {
+		notifyOffer(db, app)
+	}
+
+	return c.JSON(fiber.Map{
+		"msg":  "application withdrawn successfully",
+		"data": application,
+	})
+}
+
+// Resubmit puts a withdrawn or rejected application back in review with an edited statement,
+// as long as the project's deadline has not passed. The edit is stored as a new revision.
+func Resubmit(c *fiber.Ctx, db *gorm.DB) error {
+	id := c.Params("id")
+	var application Application
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	if err := db.Preload("Project").First(&application, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "application not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to find application"})
+	}
+
+	if !isApplicationMember(db, application.ID, userID) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you can only resubmit your own applications"})
+	}
+
+	if application.Status != StatusWithdrawn && application.Status != StatusRejected {
+		return c.Status(400).JSON(fiber.Map{"msg": "only withdrawn or rejected applications can be resubmitted"})
+	}
+
+	if application.Project.Status == project.StatusClosed || application.Project.DeadlinePassed(time.Now()) {
+		return c.Status(400).JSON(fiber.Map{"msg": "this project is closed for applications"})
+	}
+
+	type ResubmitRequest struct {
+		Statement   string            `json:"statement"`
+		Attachments []string          `json:"attachments"`
+		Answers     []ScreeningAnswer `json:"answers"`
+	}
+	var req ResubmitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid resubmission data: " + err.Error()})
+	}
+	if req.Statement == "" {
+		return c.Status(400).JSON(fiber.Map{"msg": "statement is required"})
+	}
+
+	previous := application
+	application.Statement = req.Statement
+	if req.Attachments != nil {
+		attachmentsJSON, err := json.Marshal(req.Attachments)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "invalid attachments: " + err.Error()})
+		}
+		application.Attachments = datatypes.JSON(attachmentsJSON)
+	}
+	if req.Answers != nil {
+		answersJSON, err := validateAnswers(application.Project.GetScreeningQuestions(), req.Answers)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+		}
+		application.Answers = answersJSON
+	}
+	application.OfferExpiresAt = nil
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		studentIDs := applicationStudentIDs(application)
+		if err := lockStudents(tx, studentIDs); err != nil {
+			return err
+		}
+		if err := checkApplicationConflicts(tx, application.ProjectID, studentIDs, application.ID); err != nil {
+			return err
+		}
+		if contentChanged(application, previous) {
+			if err := recordEdit(tx, &application, previous, userID); err != nil {
+				return err
+			}
+		}
+		return changeStatus(tx, &application, StatusSubmitted, userID, role, "resubmitted")
+	}); err != nil {
+		if isApplicationConflict(err) {
+			return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+		}
+		return c.Status(transitionStatus(err)).JSON(fiber.Map{"msg": "failed to resubmit application: " + err.Error()})
+	}
+
+	// Answers may have changed, so the automatic score is recomputed
+	scoreOnCreate(db, &application)
+
+	return c.JSON(fiber.Map{
+		"msg":  "application resubmitted successfully",
+		"data": application,
+	})
+}
+
+// GetRevisions returns every version of an application's statement, attachments and answers
+func GetRevisions(c *fiber.Ctx, db *gorm.DB) error {
+	id := c.Params("id")
+	var application Application
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	if err := db.Preload("Project").First(&application, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "application not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to find application"})
+	}
+
+	if !canManageApplication(db, application, userID, role) && !isApplicationMember(db, application.ID, userID) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to view this application's revisions"})
+	}
+
+	var revisions []ApplicationRevision
+	if err := db.Where("application_id = ?", application.ID).
+		Order("version ASC").
+		Find(&revisions).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get application revisions: " + err.Error()})
+	}
+	if err := redactRevisions(db, application, revisions, userID, role); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load applicants: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": revisions})
+}
@@ -0,0 +1,397 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Register decoders for thumbnails
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// maxAttachmentSize is the largest file that can be attached to a chat message
+const maxAttachmentSize = 10 * 1024 * 1024
+
+// thumbnailSize is the longest side of generated image thumbnails
+const thumbnailSize = 320
+
+// defaultUnsentAttachmentHours is how long uploads that were never sent are kept when
+// CHAT_UNSENT_ATTACHMENT_HOURS is unset or invalid
+const defaultUnsentAttachmentHours = 24
+
+// attachmentSweepInterval is how often unsent uploads are cleaned up
+const attachmentSweepInterval = time.Hour
+
+// maxThumbnailPixels caps the images decoded for thumbnails, since a small compressed file can decode
+// to a very large image
+const maxThumbnailPixels = 40_000_000
+
+// allowedAttachmentTypes are the sniffed content types accepted as chat attachments.
+// Office documents sniff as application/zip.
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"application/pdf":           true,
+	"application/zip":           true,
+	"text/plain; charset=utf-8": true,
+}
+
+// attachmentDir is where chat attachments are stored. It must not be under ./uploads, which is served
+// publicly; attachments are only served to group members through the download endpoints.
+func attachmentDir() string {
+	if dir := os.Getenv("CHAT_ATTACHMENT_DIR"); dir != "" {
+		return dir
+	}
+	return "storage/chat"
+}
+
+// UploadAttachment stores a file for the user to attach to their next message in a thread
+func UploadAttachment(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	threadID, err := strconv.ParseUint(c.Params("threadId"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid thread ID"})
+	}
+	groupID := uint(threadID)
+
+	if !isGroupMember(db, groupID, userID) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have access to this group"})
+	}
+
+	// Get uploaded file
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "no file provided: " + err.Error()})
+	}
+
+	// Validate file size
+	if file.Size > maxAttachmentSize {
+		return c.Status(400).JSON(fiber.Map{"msg": "file size exceeds 10MB limit"})
+	}
+
+	// Validate file type from its content rather than its name
+	fileHeader, err := file.Open()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to open file"})
+	}
+	buffer := make([]byte, 512)
+	n, err := fileHeader.Read(buffer)
+	fileHeader.Close()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to read file"})
+	}
+
+	contentType := http.DetectContentType(buffer[:n])
+	if !allowedAttachmentTypes[contentType] {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid file type. Only images (JPEG, PNG, GIF, WebP), PDF, ZIP/Office documents and plain text are allowed"})
+	}
+
+	// Create the group's attachment directory if it doesn't exist
+	groupDir := filepath.Join(attachmentDir(), strconv.FormatUint(uint64(groupID), 10))
+	if err := os.MkdirAll(groupDir, 0755); err != nil {
+		return c.Status(500).JSON(fiber.Map{"msg": "failed to create upload directory"})
+	}
+
+	// Generate unique filename
+	ext := filepath.Ext(file.Filename)
+	filePath := filepath.Join(groupDir, fmt.Sprintf("user_%d_%d%s", userID, time.Now().UnixNano(), ext))
+
+	// Save file
+	if err := c.SaveFile(file, filePath); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to save file: " + err.Error()})
+	}
+
+	attachment := Attachment{
+		GroupID:     groupID,
+		UploaderID:  userID,
+		FileName:    filepath.Base(file.Filename),
+		ContentType: contentType,
+		Size:        file.Size,
+		Path:        filePath,
+	}
+	if thumbnailPath, err := createThumbnail(filePath, contentType); err != nil {
+		log.Printf("Failed to create thumbnail for %s: %v", filePath, err)
+	} else {
+		attachment.ThumbnailPath = thumbnailPath
+		attachment.HasThumbnail = thumbnailPath != ""
+	}
+
+	if err := db.Create(&attachment).Error; err != nil {
+		// If saving fails, delete the uploaded files
+		removeAttachmentFiles(attachment)
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to save attachment: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"data": attachment})
+}
+
+// createThumbnail writes a scaled-down copy of a JPEG, PNG or GIF image next to it and returns its path.
+// Other types get no thumbnail.
+func createThumbnail(filePath, contentType string) (string, error) {
+	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/gif" {
+		return "", nil
+	}
+
+	in, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	config, _, err := image.DecodeConfig(in)
+	if err != nil {
+		return "", err
+	}
+	if config.Width*config.Height > maxThumbnailPixels {
+		return "", fmt.Errorf("image of %dx%d is too large for a thumbnail", config.Width, config.Height)
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	src, _, err := image.Decode(in)
+	if err != nil {
+		return "", err
+	}
+	thumb := scaleDown(src, thumbnailSize)
+
+	// Photos stay JPEG; PNG keeps the transparency of PNG and GIF images
+	thumbPath := filePath + ".thumb.png"
+	if contentType == "image/jpeg" {
+		thumbPath = filePath + ".thumb.jpg"
+	}
+	out, err := os.Create(thumbPath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(out, thumb, &jpeg.Options{Quality: 80})
+	} else {
+		err = png.Encode(out, thumb)
+	}
+	if err != nil {
+		os.Remove(thumbPath)
+		return "", err
+	}
+	return thumbPath, nil
+}
+
+// scaleDown resizes an image so its longest side is at most size, averaging the source pixels that
+// fall in each destination pixel
+func scaleDown(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := bounds.Min.Y+y*h/dh, bounds.Min.Y+(y+1)*h/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := bounds.Min.X+x*w/dw, bounds.Min.X+(x+1)*w/dw
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+					r, g, b, a = r+uint64(c.R), g+uint64(c.G), b+uint64(c.B), a+uint64(c.A)
+					n++
+				}
+			}
+			if n > 0 {
+				dst.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+			}
+		}
+	}
+	return dst
+}
+
+// removeAttachmentFiles deletes an attachment's stored file and thumbnail
+func removeAttachmentFiles(attachment Attachment) {
+	os.Remove(attachment.Path)
+	if attachment.ThumbnailPath != "" {
+		os.Remove(attachment.ThumbnailPath)
+	}
+}
+
+// createMessage saves a message and links the sender's uploaded attachments to it
+func createMessage(db *gorm.DB, message *Message, attachmentIDs []uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return linkAttachments(tx, *message, attachmentIDs)
+	})
+}
+
+// linkAttachments attaches the user's unsent uploads in a group to a message
+func linkAttachments(tx *gorm.DB, message Message, attachmentIDs []uint) error {
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+	result := tx.Model(&Attachment{}).
+		Where("id IN ? AND group_id = ? AND uploader_id = ? AND message_id IS NULL", attachmentIDs, message.GroupID, message.SenderID).
+		Update("message_id", message.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(attachmentIDs)) {
+		return errors.New("attachments must be your own unsent uploads to this group")
+	}
+	return nil
+}
+
+// Errors returned when downloading an attachment
+var (
+	errAttachmentNotFound = errors.New("attachment not found")
+	errNoGroupAccess      = errors.New("you don't have access to this group")
+)
+
+// findAttachment loads an attachment the user may download: they belong to its group or moderate it.
+// Uploads that have not been sent with a message are only visible to their uploader.
+func findAttachment(db *gorm.DB, id int, userID uint) (Attachment, error) {
+	var attachment Attachment
+	if err := db.First(&attachment, id).Error; err != nil {
+		return attachment, errAttachmentNotFound
+	}
+	if attachment.MessageID == nil && attachment.UploaderID != userID {
+		return attachment, errAttachmentNotFound
+	}
+	if !isGroupMember(db, attachment.GroupID, userID) && !canModerate(db, attachment.GroupID, userID) {
+		return attachment, errNoGroupAccess
+	}
+	return attachment, nil
+}
+
+// attachmentStatus maps attachment errors to HTTP statuses
+func attachmentStatus(err error) int {
+	switch err {
+	case errAttachmentNotFound:
+		return 404
+	case errNoGroupAccess:
+		return 403
+	default:
+		return 400
+	}
+}
+
+// DownloadAttachment serves an attachment to members of its group
+func DownloadAttachment(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid attachment ID"})
+	}
+
+	attachment, err := findAttachment(db, id, userID)
+	if err != nil {
+		return c.Status(attachmentStatus(err)).JSON(fiber.Map{"msg": err.Error()})
+	}
+
+	if err := c.SendFile(attachment.Path); err != nil {
+		return err
+	}
+
+	// Serve the sniffed type, not one guessed from the uploaded file name
+	disposition := "attachment"
+	if attachment.HasThumbnail {
+		disposition = "inline" // Images display in the thread
+	}
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("%s; filename=%q", disposition, attachment.FileName))
+	return nil
+}
+
+// DownloadThumbnail serves the thumbnail of an image attachment to members of its group
+func DownloadThumbnail(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid attachment ID"})
+	}
+
+	attachment, err := findAttachment(db, id, userID)
+	if err != nil {
+		return c.Status(attachmentStatus(err)).JSON(fiber.Map{"msg": err.Error()})
+	}
+	if !attachment.HasThumbnail {
+		return c.Status(404).JSON(fiber.Map{"msg": "attachment has no thumbnail"})
+	}
+
+	if err := c.SendFile(attachment.ThumbnailPath); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return nil
+}
+
+// unsentAttachmentMaxAge returns how long an upload may wait to be sent before it is deleted
+func unsentAttachmentMaxAge() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("CHAT_UNSENT_ATTACHMENT_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = defaultUnsentAttachmentHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// removeUnsentAttachments deletes the rows and files of uploads that were never sent with a message
+func removeUnsentAttachments(db *gorm.DB, olderThan time.Time) {
+	var attachments []Attachment
+	if err := db.Where("message_id IS NULL AND created_at < ?", olderThan).Find(&attachments).Error; err != nil {
+		log.Printf("Attachment sweeper: failed to find unsent uploads: %v", err)
+		return
+	}
+
+	for _, attachment := range attachments {
+		// Skip uploads that were sent in the meantime
+		result := db.Unscoped().Where("id = ? AND message_id IS NULL", attachment.ID).Delete(&Attachment{})
+		if result.Error != nil {
+			log.Printf("Attachment sweeper: failed to delete upload %d: %v", attachment.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		removeAttachmentFiles(attachment)
+	}
+}
+
+// StartAttachmentSweeper starts the background job that deletes uploads never sent with a message
+// (call this from main.go)
+func StartAttachmentSweeper(db *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(attachmentSweepInterval)
+		defer ticker.Stop()
+		for {
+			removeUnsentAttachments(db, time.Now().Add(-unsentAttachmentMaxAge()))
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,70 @@
+package chat
+
+import (
+	"log"
+	"os"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Broker carries broadcasts between hubs. Every hub subscribed to the broker receives each published
+// broadcast, including the hub that published it, so running several instances behind a load balancer
+// keeps every group's clients in the same conversation.
+type Broker interface {
+	// Publish sends a broadcast to every subscribed hub
+	Publish(broadcast *Broadcast) error
+	// Subscribe registers the function that receives published broadcasts
+	Subscribe(deliver func(*Broadcast)) error
+	// Close stops delivering broadcasts
+	Close() error
+}
+
+// NewBroker returns the broker selected by CHAT_BROKER: "postgres" fans out through the database so
+// every instance receives broadcasts; anything else keeps them in this process
+func NewBroker(db *gorm.DB) Broker {
+	switch os.Getenv("CHAT_BROKER") {
+	case "postgres":
+		return NewPostgresBroker(db, postgresBrokerChannel)
+	case "", "memory":
+		return NewInProcessBroker()
+	default:
+		log.Printf("Unknown CHAT_BROKER %q, using the in-process broker", os.Getenv("CHAT_BROKER"))
+		return NewInProcessBroker()
+	}
+}
+
+// InProcessBroker delivers broadcasts to the hubs of this process only
+type InProcessBroker struct {
+	mu          sync.RWMutex
+	subscribers []func(*Broadcast)
+}
+
+// NewInProcessBroker creates a broker for a single instance
+func NewInProcessBroker() *InProcessBroker {
+	return &InProcessBroker{}
+}
+
+func (b *InProcessBroker) Publish(broadcast *Broadcast) error {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+	for _, deliver := range subscribers {
+		deliver(broadcast)
+	}
+	return nil
+}
+
+func (b *InProcessBroker) Subscribe(deliver func(*Broadcast)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, deliver)
+	return nil
+}
+
+func (b *InProcessBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = nil
+	return nil
+}
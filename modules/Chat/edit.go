@@ -0,0 +1,231 @@
+package chat
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Errors returned when changing a message
+var (
+	errMessageNotFound = errors.New("message not found")
+	errMessageRemoved  = errors.New("message has been deleted")
+	errNotSender       = errors.New("only the sender can edit a message")
+	errCannotRemove    = errors.New("only the sender, the group leader or the project supervisor can delete a message")
+	errEmptyBody       = errors.New("message body is required")
+)
+
+// canModerate reports whether a user leads a group or supervises a project assigned to it
+func canModerate(db *gorm.DB, groupID, userID uint) bool {
+	var count int64
+	db.Model(&user.Group{}).Where("id = ? AND user_id = ?", groupID, userID).Count(&count)
+	if count > 0 {
+		return true
+	}
+
+	db.Table("applications").
+		Joins("JOIN projects ON projects.id = applications.project_id").
+		Where("applications.group_id = ? AND applications.status = ? AND applications.deleted_at IS NULL AND projects.supervisor_id = ?", groupID, "ASSIGNED", userID).
+		Count(&count)
+	return count > 0
+}
+
+// findMessage loads a message that has not been deleted
+func findMessage(db *gorm.DB, id uint) (Message, error) {
+	var message Message
+	if err := db.First(&message, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return message, errMessageNotFound
+		}
+		return message, err
+	}
+	if message.RemovedAt != nil {
+		return message, errMessageRemoved
+	}
+	return message, nil
+}
+
+// validReplyTo checks that a quoted message belongs to the same group
+func validReplyTo(db *gorm.DB, replyToID *uint, groupID uint) error {
+	if replyToID == nil {
+		return nil
+	}
+	var count int64
+	db.Model(&Message{}).Where("id = ? AND group_id = ?", *replyToID, groupID).Count(&count)
+	if count == 0 {
+		return errors.New("replied-to message not found in this group")
+	}
+	return nil
+}
+
+// editMessage replaces a message's body, keeping the previous body in its history. Only the sender can edit.
+func editMessage(db *gorm.DB, id, userID uint, body string) (Message, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return Message{}, errEmptyBody
+	}
+
+	message, err := findMessage(db, id)
+	if err != nil {
+		return message, err
+	}
+	if message.SenderID != userID {
+		return message, errNotSender
+	}
+	if message.Body == body {
+		return loadMessage(db, message.ID)
+	}
+
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&MessageEdit{
+			MessageID:    message.ID,
+			EditorID:     userID,
+			PreviousBody: message.Body,
+			EditedAt:     now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&message).Updates(map[string]interface{}{"body": body, "edited_at": now}).Error
+	})
+	if err != nil {
+		return message, err
+	}
+	return loadMessage(db, message.ID)
+}
+
+// removeMessage turns a message into a tombstone: its body, attachments and edit history are dropped and it
+// stays in the thread marked as deleted. The sender, the group leader and the project supervisor can delete.
+func removeMessage(db *gorm.DB, id, userID uint) (Message, error) {
+	message, err := findMessage(db, id)
+	if err != nil {
+		return message, err
+	}
+	if message.SenderID != userID && !canModerate(db, message.GroupID, userID) {
+		return message, errCannotRemove
+	}
+
+	var attachments []Attachment
+	db.Where("message_id = ?", message.ID).Find(&attachments)
+
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("message_id = ?", message.ID).Delete(&MessageEdit{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("message_id = ?", message.ID).Delete(&Attachment{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&message).Updates(map[string]interface{}{"body": "", "removed_at": now, "removed_by_id": userID}).Error
+	})
+	if err != nil {
+		return message, err
+	}
+	for _, attachment := range attachments {
+		removeAttachmentFiles(attachment)
+	}
+	return loadMessage(db, message.ID)
+}
+
+// broadcastChange pushes an edited or deleted message to its group
+func broadcastChange(envelopeType string, message Message) {
+	if chatHub == nil {
+		return
+	}
+	envelope, err := newEnvelope(envelopeType, message.GroupID, message)
+	if err != nil {
+		return
+	}
+	chatHub.Publish(&Broadcast{GroupID: message.GroupID, Envelope: envelope})
+}
+
+// changeStatus maps message change errors to HTTP statuses
+func changeStatus(err error) int {
+	switch err {
+	case errMessageNotFound:
+		return 404
+	case errNotSender, errCannotRemove:
+		return 403
+	default:
+		return 400
+	}
+}
+
+// messageID parses the :id route parameter
+func messageID(c *fiber.Ctx) (uint, bool) {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// EditMessage replaces the body of one of the user's messages
+func EditMessage(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	id, ok := messageID(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid message ID"})
+	}
+
+	type EditRequest struct {
+		Body string `json:"body"`
+	}
+	var req EditRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid request data: " + err.Error()})
+	}
+
+	message, err := editMessage(db, id, userID, req.Body)
+	if err != nil {
+		return c.Status(changeStatus(err)).JSON(fiber.Map{"msg": "failed to edit message: " + err.Error()})
+	}
+	broadcastChange(EnvelopeEdit, message)
+
+	return c.JSON(fiber.Map{"data": message})
+}
+
+// DeleteMessage leaves a tombstone in place of a message
+func DeleteMessage(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	id, ok := messageID(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid message ID"})
+	}
+
+	message, err := removeMessage(db, id, userID)
+	if err != nil {
+		return c.Status(changeStatus(err)).JSON(fiber.Map{"msg": "failed to delete message: " + err.Error()})
+	}
+	broadcastChange(EnvelopeDelete, message)
+
+	return c.JSON(fiber.Map{"data": message})
+}
+
+// GetMessageHistory lists the earlier versions of a message, oldest first
+func GetMessageHistory(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	id, ok := messageID(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid message ID"})
+	}
+
+	var message Message
+	if err := db.First(&message, id).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"msg": "message not found"})
+	}
+	if !isGroupMember(db, message.GroupID, userID) && !canModerate(db, message.GroupID, userID) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have access to this group"})
+	}
+
+	var edits []MessageEdit
+	if err := db.Where("message_id = ?", message.ID).Order("edited_at ASC").Find(&edits).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get message history: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": edits})
+}
@@ -8,13 +8,67 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-// Hub maintains the set of active clients and broadcasts messages to clients
+// Envelope types. Clients send subscribe, unsubscribe, message, edit, delete, typing and read; the server
+// pushes message, edit, delete, typing, read, notification and membership events and answers requests
+// with ack or error.
+const (
+	EnvelopeSubscribe    = "subscribe"
+	EnvelopeUnsubscribe  = "unsubscribe"
+	EnvelopeMessage      = "message"
+	EnvelopeEdit         = "edit"
+	EnvelopeDelete       = "delete"
+	EnvelopeTyping       = "typing"
+	EnvelopeRead         = "read"
+	EnvelopeNotification = "notification"
+	EnvelopeMembership   = "membership"
+	EnvelopeAck          = "ack"
+	EnvelopeError        = "error"
+)
+
+// Envelope is a single frame on a chat websocket
+type Envelope struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"` // Chosen by the client and echoed on the ack or error for its request
+	GroupID uint            `json:"groupId,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// newEnvelope builds an envelope with data marshaled to JSON
+func newEnvelope(envelopeType string, groupID uint, data interface{}) (Envelope, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{Type: envelopeType, GroupID: groupID, Data: raw}, nil
+}
+
+// Broadcast is an envelope to push to websocket clients on every instance. It reaches the clients
+// subscribed to GroupID and every client of the users in UserIDs.
+type Broadcast struct {
+	GroupID      uint     `json:"groupId,omitempty"`
+	UserIDs      []uint   `json:"userIds,omitempty"`
+	ExceptUserID uint     `json:"exceptUserId,omitempty"` // Not echoed back to this user, e.g. for typing
+	Envelope     Envelope `json:"envelope"`
+}
+
+// broadcastBuffer is how many broadcasts can wait for the hub's Run loop before the broker blocks
+const broadcastBuffer = 256
+
+// membershipChange is the data of a membership envelope
+type membershipChange struct {
+	Joined []uint `json:"joined"`
+	Left   []uint `json:"left"`
+}
+
+// Hub maintains the set of active clients and broadcasts envelopes to them
 type Hub struct {
-	// Registered clients by group ID
-	clients map[uint]map[*Client]bool
+	// Registered clients, and the same clients indexed by subscribed group and by user
+	clients map[*Client]bool
+	groups  map[uint]map[*Client]bool
+	users   map[uint]map[*Client]bool
 
-	// Inbound messages from the clients
-	broadcast chan *MessageBroadcast
+	// Broadcasts received from the broker, buffered so publishers do not wait for Run
+	broadcast chan *Broadcast
 
 	// Register requests from the clients
 	register chan *Client
@@ -22,42 +76,47 @@ type Hub struct {
 	// Unregister requests from clients
 	unregister chan *Client
 
+	// Carries broadcasts to the hubs of every instance
+	broker Broker
+
 	// Mutex for thread-safe operations
 	mu sync.RWMutex
 }
 
-// MessageBroadcast represents a message to be broadcast
-type MessageBroadcast struct {
-	GroupID  uint   `json:"group_id"`
-	Message  Message `json:"message"`
-	SenderID uint   `json:"sender_id"`
-}
-
-// Client is a middleman between the websocket connection and the hub
+// Client is one user's websocket connection, subscribed to any number of their groups
 type Client struct {
 	hub *Hub
 
 	// The websocket connection
 	conn *websocket.Conn
 
-	// Buffered channel of outbound messages
+	// Buffered channel of outbound frames
 	send chan []byte
 
-	// Group ID this client is connected to
-	groupID uint
-
 	// User ID of the client
 	userID uint
+
+	// Groups the client is subscribed to, guarded by hub.mu
+	groups map[uint]bool
 }
 
-// NewHub creates a new Hub
-func NewHub() *Hub {
-	return &Hub{
-		clients:    make(map[uint]map[*Client]bool),
-		broadcast:  make(chan *MessageBroadcast),
+// NewHub creates a new Hub that sends and receives broadcasts through the broker
+func NewHub(broker Broker) *Hub {
+	h := &Hub{
+		clients:    make(map[*Client]bool),
+		groups:     make(map[uint]map[*Client]bool),
+		users:      make(map[uint]map[*Client]bool),
+		broadcast:  make(chan *Broadcast, broadcastBuffer),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		broker:     broker,
 	}
+	if err := broker.Subscribe(func(b *Broadcast) {
+		h.broadcast <- b
+	}); err != nil {
+		log.Printf("Error subscribing chat hub to broker: %v", err)
+	}
+	return h
 }
 
 // Run starts the hub
@@ -66,70 +125,178 @@ func (h *Hub) Run() {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
-			if h.clients[client.groupID] == nil {
-				h.clients[client.groupID] = make(map[*Client]bool)
+			h.clients[client] = true
+			addClient(h.users, client.userID, client)
+			for groupID := range client.groups {
+				addClient(h.groups, groupID, client)
 			}
-			h.clients[client.groupID][client] = true
 			h.mu.Unlock()
-			log.Printf("Client registered for group %d (user %d). Total clients: %d", 
-				client.groupID, client.userID, len(h.clients[client.groupID]))
+			log.Printf("Client registered for user %d in %d groups", client.userID, len(client.groups))
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if clients, ok := h.clients[client.groupID]; ok {
-				if _, ok := clients[client]; ok {
-					delete(clients, client)
-					close(client.send)
-					if len(clients) == 0 {
-						delete(h.clients, client.groupID)
-					}
-				}
-			}
+			h.remove(client)
 			h.mu.Unlock()
-			log.Printf("Client unregistered for group %d (user %d)", client.groupID, client.userID)
-
-		case message := <-h.broadcast:
-			h.mu.RLock()
-			clients, ok := h.clients[message.GroupID]
-			h.mu.RUnlock()
-			
-			if ok {
-				// Marshal message to JSON
-				messageJSON, err := json.Marshal(message.Message)
-				if err != nil {
-					log.Printf("Error marshaling message: %v", err)
-					continue
-				}
-
-				// Broadcast to all clients in the group
-				for client := range clients {
-					select {
-					case client.send <- messageJSON:
-					default:
-						close(client.send)
-						delete(clients, client)
-					}
-				}
-			}
+			log.Printf("Client unregistered for user %d", client.userID)
+
+		case b := <-h.broadcast:
+			h.deliver(b)
 		}
 	}
 }
 
-// BroadcastMessage broadcasts a message to all clients in a group
-func (h *Hub) BroadcastMessage(groupID uint, message Message) {
-	h.broadcast <- &MessageBroadcast{
-		GroupID:  groupID,
-		Message:  message,
-		SenderID: message.SenderID,
+func addClient(index map[uint]map[*Client]bool, key uint, client *Client) {
+	if index[key] == nil {
+		index[key] = make(map[*Client]bool)
 	}
+	index[key][client] = true
 }
 
+func removeClient(index map[uint]map[*Client]bool, key uint, client *Client) {
+	if clients, ok := index[key]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(index, key)
+		}
+	}
+}
 
+// remove drops a client from every index and closes its send channel. The caller holds h.mu.
+func (h *Hub) remove(client *Client) {
+	if !h.clients[client] {
+		return
+	}
+	delete(h.clients, client)
+	removeClient(h.users, client.userID, client)
+	for groupID := range client.groups {
+		removeClient(h.groups, groupID, client)
+	}
+	close(client.send)
+}
 
+// subscribe adds a client to a group's recipients
+func (h *Hub) subscribe(client *Client, groupID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	client.groups[groupID] = true
+	if h.clients[client] {
+		addClient(h.groups, groupID, client)
+	}
+}
 
+// unsubscribe removes a client from a group's recipients
+func (h *Hub) unsubscribe(client *Client, groupID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(client.groups, groupID)
+	removeClient(h.groups, groupID, client)
+}
 
+// subscribed reports whether a client receives a group's events
+func (h *Hub) subscribed(client *Client, groupID uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return client.groups[groupID]
+}
 
+// deliver pushes a broadcast to this instance's recipients. Membership changes first subscribe the users
+// who joined and unsubscribe those who left, so joiners receive the event and leavers receive it last.
+func (h *Hub) deliver(b *Broadcast) {
+	frame, err := json.Marshal(b.Envelope)
+	if err != nil {
+		log.Printf("Error marshaling %s envelope: %v", b.Envelope.Type, err)
+		return
+	}
 
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
+	var change membershipChange
+	if b.Envelope.Type == EnvelopeMembership {
+		if err := json.Unmarshal(b.Envelope.Data, &change); err != nil {
+			log.Printf("Error parsing membership change: %v", err)
+		}
+		for _, userID := range change.Joined {
+			for client := range h.users[userID] {
+				client.groups[b.Envelope.GroupID] = true
+				addClient(h.groups, b.Envelope.GroupID, client)
+			}
+		}
+	}
+
+	recipients := make(map[*Client]bool)
+	if b.GroupID != 0 {
+		for client := range h.groups[b.GroupID] {
+			recipients[client] = true
+		}
+	}
+	for _, userID := range b.UserIDs {
+		for client := range h.users[userID] {
+			recipients[client] = true
+		}
+	}
+
+	for client := range recipients {
+		if b.ExceptUserID != 0 && client.userID == b.ExceptUserID {
+			continue
+		}
+		select {
+		case client.send <- frame:
+		default:
+			// The client is not keeping up; drop it and let it reconnect
+			h.remove(client)
+		}
+	}
+
+	for _, userID := range change.Left {
+		for client := range h.users[userID] {
+			delete(client.groups, b.Envelope.GroupID)
+			removeClient(h.groups, b.Envelope.GroupID, client)
+		}
+	}
+}
+
+// reply sends an envelope to a single client of this instance, if it is still connected
+func (h *Hub) reply(client *Client, envelope Envelope) {
+	frame, err := json.Marshal(envelope)
+	if err != nil {
+		log.Printf("Error marshaling %s envelope: %v", envelope.Type, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.clients[client] {
+		return
+	}
+	select {
+	case client.send <- frame:
+	default:
+		h.remove(client)
+	}
+}
 
+// Publish sends a broadcast to its recipients on every instance
+func (h *Hub) Publish(b *Broadcast) {
+	if err := h.broker.Publish(b); err != nil {
+		log.Printf("Error publishing %s envelope: %v", b.Envelope.Type, err)
+	}
+}
+
+// BroadcastMessage broadcasts a message to all clients subscribed to a group, on every instance
+func (h *Hub) BroadcastMessage(groupID uint, message Message) {
+	envelope, err := newEnvelope(EnvelopeMessage, groupID, message)
+	if err != nil {
+		log.Printf("Error marshaling message %d: %v", message.ID, err)
+		return
+	}
+	h.Publish(&Broadcast{GroupID: groupID, Envelope: envelope})
+}
 
+// SendToUsers pushes an envelope to every client of the given users, on every instance
+func (h *Hub) SendToUsers(userIDs []uint, envelope Envelope) {
+	if len(userIDs) == 0 {
+		return
+	}
+	h.Publish(&Broadcast{UserIDs: userIDs, Envelope: envelope})
+}
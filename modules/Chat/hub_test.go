@@ -0,0 +1,137 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestClient registers a client of a user, subscribed to the given groups, with a running hub
+func newTestClient(h *Hub, userID uint, groupIDs ...uint) *Client {
+	client := &Client{hub: h, send: make(chan []byte, 16), userID: userID, groups: make(map[uint]bool)}
+	for _, groupID := range groupIDs {
+		client.groups[groupID] = true
+	}
+	h.register <- client
+	return client
+}
+
+// receive waits for the next envelope sent to a client
+func receive(t *testing.T, client *Client, timeout time.Duration) (Envelope, bool) {
+	t.Helper()
+	select {
+	case frame := <-client.send:
+		var envelope Envelope
+		if err := json.Unmarshal(frame, &envelope); err != nil {
+			t.Fatalf("client received an invalid frame: %v", err)
+		}
+		return envelope, true
+	case <-time.After(timeout):
+		return Envelope{}, false
+	}
+}
+
+func testBroadcast(t *testing.T, b Broadcast, text string) *Broadcast {
+	t.Helper()
+	envelope, err := newEnvelope(EnvelopeTyping, b.GroupID, map[string]string{"text": text})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Envelope = envelope
+	return &b
+}
+
+func TestInProcessPublishDoesNotWaitForRun(t *testing.T) {
+	h := NewHub(NewInProcessBroker())
+
+	done := make(chan struct{})
+	go func() {
+		h.Publish(testBroadcast(t, Broadcast{GroupID: 1}, "queued"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked while the hub was not running")
+	}
+}
+
+// TestPostgresBrokerAcrossHubs runs two hubs, as two instances would, on brokers sharing one database.
+// It needs DATABASE_URL to point at a Postgres database and is skipped otherwise.
+func TestPostgresBrokerAcrossHubs(t *testing.T) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+
+	// A channel of its own keeps concurrent test runs and live instances out of the test
+	channel := fmt.Sprintf("chat_broadcasts_test_%d", time.Now().UnixNano())
+	brokerA, brokerB := NewPostgresBroker(db, channel), NewPostgresBroker(db, channel)
+	defer brokerA.Close()
+	defer brokerB.Close()
+
+	hubA, hubB := NewHub(brokerA), NewHub(brokerB)
+	go hubA.Run()
+	go hubB.Run()
+
+	// Listening starts in the background, so probe until hub B receives from hub A
+	probe := newTestClient(hubB, 999)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		hubA.Publish(testBroadcast(t, Broadcast{UserIDs: []uint{999}}, "probe"))
+		if _, ok := receive(t, probe, 200*time.Millisecond); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("hub B never received a broadcast published on hub A")
+		}
+	}
+
+	member := newTestClient(hubB, 1, 10)
+	outsider := newTestClient(hubB, 2, 20)
+	recipient := newTestClient(hubB, 3)
+	sender := newTestClient(hubA, 4, 10)
+
+	tests := []struct {
+		name      string
+		broadcast Broadcast
+		reaches   []*Client
+		skips     []*Client
+	}{
+		{"group-scoped", Broadcast{GroupID: 10}, []*Client{member, sender}, []*Client{outsider, recipient}},
+		{"user-scoped", Broadcast{UserIDs: []uint{3}}, []*Client{recipient}, []*Client{member, outsider, sender}},
+		{"group-scoped except sender", Broadcast{GroupID: 10, ExceptUserID: 4}, []*Client{member}, []*Client{sender, outsider}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hubA.Publish(testBroadcast(t, tt.broadcast, tt.name))
+
+			for _, client := range tt.reaches {
+				envelope, ok := receive(t, client, 5*time.Second)
+				if !ok {
+					t.Fatalf("user %d did not receive the broadcast", client.userID)
+				}
+				var data map[string]string
+				json.Unmarshal(envelope.Data, &data)
+				if envelope.Type != EnvelopeTyping || data["text"] != tt.name {
+					t.Errorf("user %d received %s %v, want this test's broadcast", client.userID, envelope.Type, data)
+				}
+			}
+			for _, client := range tt.skips {
+				if envelope, ok := receive(t, client, 300*time.Millisecond); ok {
+					t.Errorf("user %d unexpectedly received %s", client.userID, envelope.Data)
+				}
+			}
+		})
+	}
+}
@@ -1,15 +1,56 @@
 package chat
 
 import (
+	"time"
+
 	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
 	"gorm.io/gorm"
 )
 
 type Message struct {
 	gorm.Model
-	SenderID uint       `json:"senderId"`
-	Sender   user.User  `json:"sender" gorm:"foreignKey:SenderID"`
-	Body     string     `json:"body"`
-	GroupID  uint       `json:"groupId"`
-	Group    user.Group `json:"group" gorm:"foreignKey:GroupID"`
+	SenderID    uint         `json:"senderId"`
+	Sender      user.User    `json:"sender" gorm:"foreignKey:SenderID"`
+	Body        string       `json:"body"`
+	GroupID     uint         `json:"groupId"`
+	Group       user.Group   `json:"group" gorm:"foreignKey:GroupID"`
+	ReplyToID   *uint        `json:"replyToId,omitempty"`
+	ReplyTo     *Message     `json:"replyTo,omitempty" gorm:"foreignKey:ReplyToID"` // The quoted message
+	EditedAt    *time.Time   `json:"editedAt,omitempty"`
+	RemovedAt   *time.Time   `json:"removedAt,omitempty"` // Set when the message is deleted; the body is cleared and the tombstone kept in the thread
+	RemovedByID *uint        `json:"removedById,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty" gorm:"foreignKey:MessageID"`
+}
+
+// Attachment is a file uploaded to a group's chat. It is uploaded first and linked to a message when the
+// message is sent; files are only served to the group through the attachment endpoints.
+type Attachment struct {
+	gorm.Model
+	MessageID     *uint  `json:"messageId" gorm:"index"` // Nil until the message is sent
+	GroupID       uint   `json:"groupId" gorm:"index;not null"`
+	UploaderID    uint   `json:"uploaderId"`
+	FileName      string `json:"fileName"`    // As uploaded, for downloads
+	ContentType   string `json:"contentType"` // Sniffed from the file's content
+	Size          int64  `json:"size"`
+	HasThumbnail  bool   `json:"hasThumbnail"`
+	Path          string `json:"-"`
+	ThumbnailPath string `json:"-"`
+}
+
+// MessageEdit keeps a message's body as it was before an edit
+type MessageEdit struct {
+	gorm.Model
+	MessageID    uint      `json:"messageId" gorm:"index;not null"`
+	EditorID     uint      `json:"editorId"`
+	PreviousBody string    `json:"previousBody"`
+	EditedAt     time.Time `json:"editedAt"`
+}
+
+// ReadCursor records the last message a user has read in a group
+type ReadCursor struct {
+	gorm.Model
+	UserID            uint      `json:"userId" gorm:"uniqueIndex:idx_read_cursor;not null"`
+	GroupID           uint      `json:"groupId" gorm:"uniqueIndex:idx_read_cursor;not null"`
+	LastReadMessageID uint      `json:"lastReadMessageId"`
+	ReadAt            time.Time `json:"readAt"`
 }
@@ -0,0 +1,182 @@
+package chat
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// postgresBrokerChannel is the LISTEN/NOTIFY channel chat broadcasts are sent on
+const postgresBrokerChannel = "chat_broadcasts"
+
+// maxNotifyPayload is kept under Postgres' 8000 byte NOTIFY payload limit
+const maxNotifyPayload = 7900
+
+// listenRetryDelay is how long the listener waits before reconnecting after losing its connection
+const listenRetryDelay = 2 * time.Second
+
+// postgresNotification is the payload of a chat notification. Message events too large for a notification
+// are sent by reference and each receiver reloads the message from the database.
+type postgresNotification struct {
+	Broadcast *Broadcast `json:"broadcast,omitempty"`
+	Type      string     `json:"type,omitempty"`
+	GroupID   uint       `json:"groupId,omitempty"`
+	MessageID uint       `json:"messageId,omitempty"`
+}
+
+// carriesMessage reports whether an envelope type's data is a Message that receivers can reload
+func carriesMessage(envelopeType string) bool {
+	return envelopeType == EnvelopeMessage || envelopeType == EnvelopeEdit || envelopeType == EnvelopeDelete
+}
+
+// PostgresBroker fans broadcasts out to every instance through Postgres LISTEN/NOTIFY on the existing
+// database connection. One pooled connection per instance is held for listening.
+type PostgresBroker struct {
+	db      *gorm.DB
+	channel string
+
+	mu          sync.RWMutex
+	subscribers []func(*Broadcast)
+	cancel      context.CancelFunc
+}
+
+// NewPostgresBroker creates a broker that notifies and listens on the given channel
+func NewPostgresBroker(db *gorm.DB, channel string) *PostgresBroker {
+	return &PostgresBroker{db: db, channel: channel}
+}
+
+func (b *PostgresBroker) Publish(broadcast *Broadcast) error {
+	payload, err := json.Marshal(postgresNotification{Broadcast: broadcast})
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		var message struct{ ID uint }
+		if !carriesMessage(broadcast.Envelope.Type) || json.Unmarshal(broadcast.Envelope.Data, &message) != nil || message.ID == 0 {
+			return fmt.Errorf("%s envelope of %d bytes is too large to publish", broadcast.Envelope.Type, len(payload))
+		}
+		payload, err = json.Marshal(postgresNotification{Type: broadcast.Envelope.Type, GroupID: broadcast.GroupID, MessageID: message.ID})
+		if err != nil {
+			return err
+		}
+	}
+	return b.db.Exec("SELECT pg_notify(?, ?)", b.channel, string(payload)).Error
+}
+
+// Subscribe registers a receiver and starts listening on the first subscription
+func (b *PostgresBroker) Subscribe(deliver func(*Broadcast)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, deliver)
+	if b.cancel == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		b.cancel = cancel
+		go b.listen(ctx)
+	}
+	return nil
+}
+
+func (b *PostgresBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel != nil {
+		b.cancel()
+		b.cancel = nil
+	}
+	b.subscribers = nil
+	return nil
+}
+
+// listen keeps a LISTEN connection open until the broker is closed, reconnecting when it drops
+func (b *PostgresBroker) listen(ctx context.Context) {
+	for {
+		err := b.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Chat broker lost its listen connection: %v. Reconnecting in %s", err, listenRetryDelay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// listenOnce takes a connection from the pool, listens on it and delivers notifications until it fails.
+// The connection is discarded afterwards rather than returned to the pool still listening.
+func (b *PostgresBroker) listenOnce(ctx context.Context) error {
+	sqlDB, err := b.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var listenErr error
+	conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			listenErr = fmt.Errorf("database driver %T does not support LISTEN", driverConn)
+			return driver.ErrBadConn
+		}
+		pgConn := stdConn.Conn()
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+			listenErr = err
+			return driver.ErrBadConn
+		}
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				listenErr = err
+				return driver.ErrBadConn
+			}
+			b.deliver(notification.Payload)
+		}
+	})
+	return listenErr
+}
+
+// deliver decodes a notification and hands the broadcast to every subscriber
+func (b *PostgresBroker) deliver(payload string) {
+	var n postgresNotification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		log.Printf("Chat broker received an invalid notification: %v", err)
+		return
+	}
+
+	broadcast := n.Broadcast
+	if broadcast == nil {
+		message, err := loadMessage(b.db, n.MessageID)
+		if err != nil {
+			log.Printf("Chat broker failed to load message %d: %v", n.MessageID, err)
+			return
+		}
+		envelope, err := newEnvelope(n.Type, n.GroupID, message)
+		if err != nil {
+			log.Printf("Chat broker failed to encode message %d: %v", n.MessageID, err)
+			return
+		}
+		broadcast = &Broadcast{GroupID: n.GroupID, Envelope: envelope}
+	}
+
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+	for _, deliver := range subscribers {
+		deliver(broadcast)
+	}
+}
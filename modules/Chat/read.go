@@ -0,0 +1,122 @@
+package chat
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// markRead moves a user's read cursor in a group forward to the given message. Cursors never move back,
+// so the returned cursor may be ahead of messageID.
+func markRead(db *gorm.DB, userID, groupID, messageID uint) (ReadCursor, error) {
+	var count int64
+	if err := db.Model(&Message{}).Where("id = ? AND group_id = ?", messageID, groupID).Count(&count).Error; err != nil {
+		return ReadCursor{}, err
+	}
+	if count == 0 {
+		return ReadCursor{}, errors.New("message not found in this group")
+	}
+
+	now := time.Now()
+	cursor := ReadCursor{UserID: userID, GroupID: groupID, LastReadMessageID: messageID, ReadAt: now}
+	if err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "group_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_message_id": gorm.Expr("GREATEST(read_cursors.last_read_message_id, EXCLUDED.last_read_message_id)"),
+			"read_at":              now,
+			"updated_at":           now,
+		}),
+	}).Create(&cursor).Error; err != nil {
+		return ReadCursor{}, err
+	}
+
+	err := db.Where("user_id = ? AND group_id = ?", userID, groupID).First(&cursor).Error
+	return cursor, err
+}
+
+// broadcastRead tells a group's members how far a user has read, for "seen by"
+func broadcastRead(cursor ReadCursor) {
+	if chatHub == nil {
+		return
+	}
+	envelope, err := newEnvelope(EnvelopeRead, cursor.GroupID, cursor)
+	if err != nil {
+		return
+	}
+	chatHub.Publish(&Broadcast{GroupID: cursor.GroupID, Envelope: envelope})
+}
+
+// unreadCount counts the messages from other members after a user's read cursor
+func unreadCount(db *gorm.DB, userID, groupID uint) (int64, error) {
+	var lastRead uint
+	db.Model(&ReadCursor{}).Where("user_id = ? AND group_id = ?", userID, groupID).Select("last_read_message_id").Scan(&lastRead)
+
+	var count int64
+	err := db.Model(&Message{}).
+		Where("group_id = ? AND id > ? AND sender_id <> ? AND removed_at IS NULL", groupID, lastRead, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkThreadRead moves the user's read cursor for a thread to messageId, or to its latest message
+func MarkThreadRead(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	threadID, err := strconv.ParseUint(c.Params("threadId"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid thread ID"})
+	}
+	groupID := uint(threadID)
+
+	if !isGroupMember(db, groupID, userID) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have access to this group"})
+	}
+
+	type ReadRequest struct {
+		MessageID uint `json:"messageId"`
+	}
+	var req ReadRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "invalid request data: " + err.Error()})
+		}
+	}
+	if req.MessageID == 0 {
+		db.Model(&Message{}).Where("group_id = ?", groupID).Select("COALESCE(MAX(id), 0)").Scan(&req.MessageID)
+		if req.MessageID == 0 {
+			return c.Status(400).JSON(fiber.Map{"msg": "thread has no messages"})
+		}
+	}
+
+	cursor, err := markRead(db, userID, groupID, req.MessageID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to mark thread read: " + err.Error()})
+	}
+	broadcastRead(cursor)
+
+	return c.JSON(fiber.Map{"data": cursor})
+}
+
+// GetThreadReads returns every member's read cursor for a thread; a message is seen by the members whose
+// lastReadMessageId is at or after it
+func GetThreadReads(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	threadID, err := strconv.ParseUint(c.Params("threadId"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid thread ID"})
+	}
+
+	if !isGroupMember(db, uint(threadID), userID) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have access to this group"})
+	}
+
+	var cursors []ReadCursor
+	if err := db.Where("group_id = ?", uint(threadID)).Order("last_read_message_id DESC").Find(&cursors).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get read receipts: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": cursors})
+}
@@ -1,6 +1,9 @@
 package chat
 
 import (
+	"log"
+
+	notification "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Notification"
 	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -8,15 +11,43 @@ import (
 
 var chatHub *Hub
 
-// InitHub initializes the chat hub (call this from main.go)
-func InitHub() {
-	chatHub = NewHub()
+// InitHub initializes the chat hub with the broker selected by CHAT_BROKER (call this from main.go)
+func InitHub(db *gorm.DB) {
+	chatHub = NewHub(NewBroker(db))
 	go chatHub.Run()
 }
 
+// PushNotification sends a newly created notification to the user's open chat connections
+// (register it with notification.OnCreated)
+func PushNotification(n notification.Notification) {
+	if chatHub == nil {
+		return
+	}
+	envelope, err := newEnvelope(EnvelopeNotification, 0, n)
+	if err != nil {
+		log.Printf("Error marshaling notification %d: %v", n.ID, err)
+		return
+	}
+	chatHub.SendToUsers([]uint{n.UserID}, envelope)
+}
+
+// PushMembershipChange subscribes the open connections of users who joined a group, unsubscribes those
+// who left, and tells the group (register it with user.OnMembershipChanged)
+func PushMembershipChange(groupID uint, joined, left []uint) {
+	if chatHub == nil {
+		return
+	}
+	envelope, err := newEnvelope(EnvelopeMembership, groupID, membershipChange{Joined: joined, Left: left})
+	if err != nil {
+		log.Printf("Error marshaling membership change for group %d: %v", groupID, err)
+		return
+	}
+	chatHub.Publish(&Broadcast{GroupID: groupID, UserIDs: append(append([]uint{}, joined...), left...), Envelope: envelope})
+}
+
 func RegisterRoutes(r fiber.Router, db *gorm.DB) {
 	if chatHub == nil {
-		InitHub()
+		InitHub(db)
 	}
 
 	chats := r.Group("/chats", user.JWTProtect([]string{"*"}))
@@ -32,11 +63,46 @@ func RegisterRoutes(r fiber.Router, db *gorm.DB) {
 		return GetThreadsByUser(c, db)
 	})
 
+	// Read receipts for a thread (must come before /:group)
+	chats.Post("/threads/:threadId/read", func(c *fiber.Ctx) error {
+		return MarkThreadRead(c, db)
+	})
+
+	chats.Get("/threads/:threadId/reads", func(c *fiber.Ctx) error {
+		return GetThreadReads(c, db)
+	})
+
 	// Get messages for a thread (must come before /:group)
 	chats.Get("/threads/:threadId/messages", func(c *fiber.Ctx) error {
 		return GetMessagesByThread(c, db)
 	})
 
+	// Attachments are uploaded to a thread, then sent with a message; downloads are limited to the group
+	chats.Post("/threads/:threadId/attachments", func(c *fiber.Ctx) error {
+		return UploadAttachment(c, db)
+	})
+
+	chats.Get("/attachments/:id", func(c *fiber.Ctx) error {
+		return DownloadAttachment(c, db)
+	})
+
+	chats.Get("/attachments/:id/thumbnail", func(c *fiber.Ctx) error {
+		return DownloadThumbnail(c, db)
+	})
+
+	// Edit, delete and history of a single message
+	chats.Put("/messages/:id", func(c *fiber.Ctx) error {
+		return EditMessage(c, db)
+	})
+
+	chats.Delete("/messages/:id", func(c *fiber.Ctx) error {
+		return DeleteMessage(c, db)
+	})
+
+	chats.Get("/messages/:id/history", func(c *fiber.Ctx) error {
+		return GetMessageHistory(c, db)
+	})
+
 	// Get messages by project ID (finds ASSIGNED application, gets group, then messages)
 	chats.Get("/project/:projectId", func(c *fiber.Ctx) error {
 		return GetMessagesByProject(c, db)
@@ -1,7 +1,10 @@
 package chat
 
 import (
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
 	"github.com/gofiber/fiber/v2"
@@ -28,7 +31,7 @@ func FindAll(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "invalid group ID"})
 	}
 
-	if err := db.Where("group_id = ?", GroupID).Preload("Sender").Order("created_at ASC").Find(&messages).Error; err != nil {
+	if err := withRelations(db).Where("group_id = ?", GroupID).Order("created_at ASC").Find(&messages).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get the messages for the provided group: " + err.Error()})
 	}
 
@@ -39,10 +42,12 @@ func FindAll(c *fiber.Ctx, db *gorm.DB) error {
 func Create(c *fiber.Ctx, db *gorm.DB) error {
 	// Request struct to handle both snake_case (group_id) and camelCase (groupId) from frontend
 	type CreateMessageRequest struct {
-		GroupID  uint   `json:"groupId"`  // camelCase
-		Group_ID uint   `json:"group_id"` // snake_case (for compatibility)
-		Body     string `json:"body"`
-		Type     string `json:"type,omitempty"`
+		GroupID       uint   `json:"groupId"`  // camelCase
+		Group_ID      uint   `json:"group_id"` // snake_case (for compatibility)
+		Body          string `json:"body"`
+		Type          string `json:"type,omitempty"`
+		ReplyToID     *uint  `json:"replyToId,omitempty"`
+		AttachmentIDs []uint `json:"attachmentIds,omitempty"` // Uploaded first through /threads/:threadId/attachments
 	}
 
 	var req CreateMessageRequest
@@ -71,26 +76,68 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to validate group: " + err.Error()})
 	}
 
+	if strings.TrimSpace(req.Body) == "" && len(req.AttachmentIDs) == 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "body or attachmentIds is required"})
+	}
+
+	if err := validReplyTo(db, req.ReplyToID, groupID); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+	}
+
 	// Create message
 	message := Message{
-		SenderID: UserID,
-		GroupID:  groupID,
-		Body:     req.Body,
+		SenderID:  UserID,
+		GroupID:   groupID,
+		Body:      req.Body,
+		ReplyToID: req.ReplyToID,
 	}
 
-	if err := db.Create(&message).Error; err != nil {
+	if err := createMessage(db, &message, req.AttachmentIDs); err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to send message: " + err.Error()})
 	}
 
 	// Load sender info (Profile is embedded, not a relation, so no need to preload it separately)
-	db.Preload("Sender").First(&message, message.ID)
+	message, _ = loadMessage(db, message.ID)
+	markRead(db, UserID, groupID, message.ID) // Senders have read their own messages
 
-	// WebSocket broadcasting removed - using plain HTTP only
-	// Clients can poll or refresh to get new messages
+	// Push the message to the group's websocket clients on every instance
+	if chatHub != nil {
+		chatHub.BroadcastMessage(groupID, message)
+	}
 
 	return c.Status(201).JSON(fiber.Map{"data": message})
 }
 
+// userGroupIDs returns the groups a user leads or belongs to
+func userGroupIDs(db *gorm.DB, userID uint) ([]uint, error) {
+	var groupIDs []uint
+	err := db.Model(&user.Group{}).
+		Where("user_id = ? OR id IN (SELECT group_id FROM user_groups WHERE user_id = ?)", userID, userID).
+		Pluck("id", &groupIDs).Error
+	return groupIDs, err
+}
+
+// isGroupMember reports whether a user leads or belongs to a group
+func isGroupMember(db *gorm.DB, groupID, userID uint) bool {
+	var count int64
+	db.Model(&user.Group{}).
+		Where("id = ? AND (user_id = ? OR id IN (SELECT group_id FROM user_groups WHERE user_id = ?))", groupID, userID, userID).
+		Count(&count)
+	return count > 0
+}
+
+// withRelations preloads the relations sent to clients with each message
+func withRelations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Sender").Preload("Attachments").Preload("ReplyTo").Preload("ReplyTo.Sender")
+}
+
+// loadMessage loads a message with the relations sent to clients
+func loadMessage(db *gorm.DB, id uint) (Message, error) {
+	var message Message
+	err := withRelations(db).First(&message, id).Error
+	return message, err
+}
+
 // GetThreadsByUser gets all chat threads (groups) for a user
 func GetThreadsByUser(c *fiber.Ctx, db *gorm.DB) error {
 	// Get userID from JWT token (route is protected, so token is required)
@@ -130,12 +177,17 @@ func GetThreadsByUser(c *fiber.Ctx, db *gorm.DB) error {
 
 	// Transform to thread format
 	type Thread struct {
-		ID             uint   `json:"id"`
-		ProjectID      uint   `json:"projectId"`
-		Type           string `json:"type"`
-		ParticipantIDs []uint `json:"participantIds"`
-		CreatedAt      string `json:"createdAt"`
-		UpdatedAt      string `json:"updatedAt"`
+		ID             uint     `json:"id"`
+		ProjectID      uint     `json:"projectId"`
+		Type           string   `json:"type"`
+		ParticipantIDs []uint   `json:"participantIds"`
+		UnreadCount    int64    `json:"unreadCount"`
+		LastMessage    *Message `json:"lastMessage"`
+		LastActivityAt string   `json:"lastActivityAt"` // Latest message, or the group's last update when it has none
+		CreatedAt      string   `json:"createdAt"`
+		UpdatedAt      string   `json:"updatedAt"`
+
+		lastActivity time.Time
 	}
 
 	var threads []Thread
@@ -171,16 +223,43 @@ func GetThreadsByUser(c *fiber.Ctx, db *gorm.DB) error {
 			participantIDs = append(participantIDs, member.ID)
 		}
 
+		unread, err := unreadCount(db, userID, group.ID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "failed to count unread messages: " + err.Error()})
+		}
+
+		var lastMessage *Message
+		lastActivity := group.UpdatedAt
+		var lastMessageID uint
+		db.Model(&Message{}).Where("group_id = ?", group.ID).Select("COALESCE(MAX(id), 0)").Scan(&lastMessageID)
+		if lastMessageID > 0 {
+			if message, err := loadMessage(db, lastMessageID); err == nil {
+				lastMessage = &message
+				if message.CreatedAt.After(lastActivity) {
+					lastActivity = message.CreatedAt
+				}
+			}
+		}
+
 		threads = append(threads, Thread{
 			ID:             group.ID,
 			ProjectID:      projectID,
 			Type:           "PROJECT",
 			ParticipantIDs: participantIDs,
+			UnreadCount:    unread,
+			LastMessage:    lastMessage,
+			LastActivityAt: lastActivity.Format("2006-01-02T15:04:05Z07:00"),
 			CreatedAt:      group.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt:      group.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			lastActivity:   lastActivity,
 		})
 	}
 
+	// Most recently active threads first
+	sort.SliceStable(threads, func(i, j int) bool {
+		return threads[i].lastActivity.After(threads[j].lastActivity)
+	})
+
 	// Always return data, even if empty array
 	return c.JSON(fiber.Map{"data": threads})
 }
@@ -198,7 +277,7 @@ func GetMessagesByThread(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "invalid thread ID"})
 	}
 
-	if err := db.Where("group_id = ?", ThreadID).Preload("Sender").Order("created_at ASC").Find(&messages).Error; err != nil {
+	if err := withRelations(db).Where("group_id = ?", ThreadID).Order("created_at ASC").Find(&messages).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get the messages for the provided thread: " + err.Error()})
 	}
 
@@ -245,8 +324,7 @@ func GetMessagesByProject(c *fiber.Ctx, db *gorm.DB) error {
 
 	// Step 3: Query messages with that group ID
 	var messages []Message
-	if err := db.Where("group_id = ?", *application.GroupID).
-		Preload("Sender").
+	if err := withRelations(db).Where("group_id = ?", *application.GroupID).
 		Order("created_at ASC").
 		Find(&messages).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get messages for the group: " + err.Error()})
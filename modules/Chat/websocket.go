@@ -2,12 +2,14 @@ package chat
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
-	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gorilla/websocket"
@@ -45,43 +47,28 @@ var upgrader = websocket.Upgrader{
 	WriteBufferSize: 1024,
 }
 
-// HandleWebSocket handles WebSocket connections
+// HandleWebSocket opens one connection for the authenticated user, subscribed to every group they lead or
+// belong to. Passing ?group= subscribes to that group only, for clients that want a single thread.
 func HandleWebSocket(c *fiber.Ctx, db *gorm.DB, hub *Hub) error {
 	// Get user ID from JWT
 	userID := c.Locals("user_id").(uint)
 
-	// Get group ID from query parameter
-	groupIDStr := c.Query("group")
-	if groupIDStr == "" {
-		return c.Status(400).JSON(fiber.Map{"msg": "group parameter is required"})
-	}
-
-	groupID, err := strconv.ParseUint(groupIDStr, 10, 32)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"msg": "invalid group ID"})
-	}
-
-	// Verify user has access to this group
-	var group user.Group
-	if err := db.Preload("Members").First(&group, uint(groupID)).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"msg": "group not found"})
-	}
-
-	// Check if user is a member or leader
-	isMember := false
-	if group.UserID == userID {
-		isMember = true
+	var groupIDs []uint
+	if groupIDStr := c.Query("group"); groupIDStr != "" {
+		groupID, err := strconv.ParseUint(groupIDStr, 10, 32)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "invalid group ID"})
+		}
+		if !isGroupMember(db, uint(groupID), userID) {
+			return c.Status(403).JSON(fiber.Map{"msg": "you don't have access to this group"})
+		}
+		groupIDs = []uint{uint(groupID)}
 	} else {
-		for _, member := range group.Members {
-			if member.ID == userID {
-				isMember = true
-				break
-			}
+		ids, err := userGroupIDs(db, userID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "failed to get groups: " + err.Error()})
 		}
-	}
-
-	if !isMember {
-		return c.Status(403).JSON(fiber.Map{"msg": "you don't have access to this group"})
+		groupIDs = ids
 	}
 
 	// Convert Fiber context to HTTP request/response for WebSocket upgrade
@@ -100,11 +87,14 @@ func HandleWebSocket(c *fiber.Ctx, db *gorm.DB, hub *Hub) error {
 	}
 
 	client := &Client{
-		hub:     hub,
-		conn:    conn,
-		send:    make(chan []byte, 256),
-		groupID: uint(groupID),
-		userID:  userID,
+		hub:    hub,
+		conn:   conn,
+		send:   make(chan []byte, 256),
+		userID: userID,
+		groups: make(map[uint]bool, len(groupIDs)),
+	}
+	for _, groupID := range groupIDs {
+		client.groups[groupID] = true
 	}
 
 	hub.register <- client
@@ -116,7 +106,7 @@ func HandleWebSocket(c *fiber.Ctx, db *gorm.DB, hub *Hub) error {
 	return nil
 }
 
-// readPump pumps messages from the websocket connection to the hub
+// readPump reads envelopes from the websocket connection and handles them
 func (c *Client) readPump(db *gorm.DB, hub *Hub) {
 	defer func() {
 		c.hub.unregister <- c
@@ -131,7 +121,7 @@ func (c *Client) readPump(db *gorm.DB, hub *Hub) {
 	})
 
 	for {
-		_, messageBytes, err := c.conn.ReadMessage()
+		_, frame, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
@@ -139,35 +129,134 @@ func (c *Client) readPump(db *gorm.DB, hub *Hub) {
 			break
 		}
 
-		// Parse incoming message
-		var incomingMessage struct {
-			Body string `json:"body"`
-			Type string `json:"type"`
+		var envelope Envelope
+		if err := json.Unmarshal(frame, &envelope); err != nil {
+			hub.reply(c, Envelope{Type: EnvelopeError, Data: errorData("invalid envelope: " + err.Error())})
+			continue
 		}
 
-		if err := json.Unmarshal(messageBytes, &incomingMessage); err != nil {
-			log.Printf("Error parsing message: %v", err)
-			continue
+		if err := c.handle(db, hub, envelope); err != nil {
+			hub.reply(c, Envelope{Type: EnvelopeError, ID: envelope.ID, GroupID: envelope.GroupID, Data: errorData(err.Error())})
 		}
+	}
+}
 
-		// Create message in database
-		message := Message{
-			SenderID: c.userID,
-			GroupID:  c.groupID,
-			Body:     incomingMessage.Body,
+// errorData is the data of an error envelope
+func errorData(msg string) json.RawMessage {
+	data, _ := json.Marshal(fiber.Map{"msg": msg})
+	return data
+}
+
+// ack confirms a request, echoing its ID
+func (c *Client) ack(hub *Hub, request Envelope, data interface{}) {
+	envelope, err := newEnvelope(EnvelopeAck, request.GroupID, data)
+	if err != nil {
+		log.Printf("Error marshaling ack: %v", err)
+		return
+	}
+	envelope.ID = request.ID
+	hub.reply(c, envelope)
+}
+
+// handle acts on one envelope from the client
+func (c *Client) handle(db *gorm.DB, hub *Hub, envelope Envelope) error {
+	switch envelope.Type {
+	case EnvelopeSubscribe:
+		if !isGroupMember(db, envelope.GroupID, c.userID) {
+			return errors.New("you don't have access to this group")
+		}
+		hub.subscribe(c, envelope.GroupID)
+		c.ack(hub, envelope, nil)
+
+	case EnvelopeUnsubscribe:
+		hub.unsubscribe(c, envelope.GroupID)
+		c.ack(hub, envelope, nil)
+
+	case EnvelopeMessage:
+		if !hub.subscribed(c, envelope.GroupID) {
+			return errors.New("subscribe to the group before sending to it")
+		}
+		var data struct {
+			Body          string `json:"body"`
+			ReplyToID     *uint  `json:"replyToId"`
+			AttachmentIDs []uint `json:"attachmentIds"`
+		}
+		if err := json.Unmarshal(envelope.Data, &data); err != nil || (strings.TrimSpace(data.Body) == "" && len(data.AttachmentIDs) == 0) {
+			return errors.New("message body or attachmentIds is required")
+		}
+		if err := validReplyTo(db, data.ReplyToID, envelope.GroupID); err != nil {
+			return err
 		}
 
-		if err := db.Create(&message).Error; err != nil {
+		message := Message{
+			SenderID:  c.userID,
+			GroupID:   envelope.GroupID,
+			Body:      data.Body,
+			ReplyToID: data.ReplyToID,
+		}
+		if err := createMessage(db, &message, data.AttachmentIDs); err != nil {
 			log.Printf("Error saving message: %v", err)
-			continue
+			return errors.New("failed to send message: " + err.Error())
 		}
+		message, _ = loadMessage(db, message.ID)
+		markRead(db, c.userID, envelope.GroupID, message.ID) // Senders have read their own messages
 
-		// Load sender info
-		db.Preload("Sender").First(&message, message.ID)
+		c.ack(hub, envelope, message)
+		hub.BroadcastMessage(envelope.GroupID, message)
 
-		// Broadcast to all clients in the group
-		hub.BroadcastMessage(c.groupID, message)
+	case EnvelopeEdit, EnvelopeDelete:
+		var data struct {
+			MessageID uint   `json:"messageId"`
+			Body      string `json:"body"`
+		}
+		if err := json.Unmarshal(envelope.Data, &data); err != nil || data.MessageID == 0 {
+			return errors.New("messageId is required")
+		}
+
+		var message Message
+		var err error
+		if envelope.Type == EnvelopeEdit {
+			message, err = editMessage(db, data.MessageID, c.userID, data.Body)
+		} else {
+			message, err = removeMessage(db, data.MessageID, c.userID)
+		}
+		if err != nil {
+			return err
+		}
+		c.ack(hub, envelope, message)
+		broadcastChange(envelope.Type, message)
+
+	case EnvelopeTyping:
+		if !hub.subscribed(c, envelope.GroupID) {
+			return errors.New("subscribe to the group before sending to it")
+		}
+		typing, err := newEnvelope(EnvelopeTyping, envelope.GroupID, fiber.Map{"userId": c.userID})
+		if err != nil {
+			return err
+		}
+		hub.Publish(&Broadcast{GroupID: envelope.GroupID, ExceptUserID: c.userID, Envelope: typing})
+
+	case EnvelopeRead:
+		if !hub.subscribed(c, envelope.GroupID) {
+			return errors.New("subscribe to the group before sending to it")
+		}
+		var data struct {
+			MessageID uint `json:"messageId"`
+		}
+		if err := json.Unmarshal(envelope.Data, &data); err != nil || data.MessageID == 0 {
+			return errors.New("messageId is required")
+		}
+		cursor, err := markRead(db, c.userID, envelope.GroupID, data.MessageID)
+		if err != nil {
+			return err
+		}
+		c.ack(hub, envelope, cursor)
+		broadcastRead(cursor)
+
+	default:
+		return fmt.Errorf("unknown envelope type %q", envelope.Type)
 	}
+	return nil
 }
 
 // writePump pumps messages from the hub to the websocket connection
@@ -188,20 +277,8 @@ func (c *Client) writePump() {
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
-			}
-			w.Write(message)
-
-			// Add queued messages to the current websocket message
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
-			}
-
-			if err := w.Close(); err != nil {
+			// Each envelope goes in its own frame so clients can parse frames as JSON
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
 
@@ -0,0 +1,161 @@
+package exchangerate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultReportingCurrency is used when neither the request nor REPORTING_CURRENCY names one
+const defaultReportingCurrency = "USD"
+
+// ReportingCurrency returns the requested reporting currency, falling back to REPORTING_CURRENCY
+// and then USD
+func ReportingCurrency(requested string) string {
+	if code := Normalize(requested); code != "" {
+		return code
+	}
+	if code := Normalize(os.Getenv("REPORTING_CURRENCY")); code != "" {
+		return code
+	}
+	return defaultReportingCurrency
+}
+
+// Normalize upper-cases a currency code
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// pair identifies a directed currency pair
+type pair struct {
+	base, quote string
+}
+
+// Converter converts amounts between currencies using the stored rates. It loads every rate once,
+// so create one per request rather than keeping it around.
+type Converter struct {
+	rates      map[pair][]ExchangeRate // Sorted by EffectiveFrom, oldest first
+	currencies []string                // Every currency with a stored rate, sorted
+	missing    map[string]bool
+}
+
+// NewConverter loads the exchange rates into a converter
+func NewConverter(db *gorm.DB) (*Converter, error) {
+	var rates []ExchangeRate
+	if err := db.Order("effective_from ASC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return newConverter(rates), nil
+}
+
+// newConverter indexes rates by pair, oldest first
+func newConverter(rates []ExchangeRate) *Converter {
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].EffectiveFrom.Before(rates[j].EffectiveFrom) })
+
+	conv := &Converter{rates: map[pair][]ExchangeRate{}, missing: map[string]bool{}}
+	seen := map[string]bool{}
+	for _, rate := range rates {
+		key := pair{rate.BaseCurrency, rate.QuoteCurrency}
+		conv.rates[key] = append(conv.rates[key], rate)
+		for _, code := range []string{rate.BaseCurrency, rate.QuoteCurrency} {
+			if !seen[code] {
+				seen[code] = true
+				conv.currencies = append(conv.currencies, code)
+			}
+		}
+	}
+	sort.Strings(conv.currencies)
+	return conv
+}
+
+// direct returns the rate for base -> quote in effect at the given time, using the inverse of a
+// quote -> base rate when only that one is stored
+func (conv *Converter) direct(base, quote string, at time.Time) (float64, bool) {
+	if rate, ok := effective(conv.rates[pair{base, quote}], at); ok {
+		return rate, true
+	}
+	if rate, ok := effective(conv.rates[pair{quote, base}], at); ok && rate != 0 {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+// effective returns the latest rate that had taken effect at the given time
+func effective(rates []ExchangeRate, at time.Time) (float64, bool) {
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].EffectiveFrom.After(at) })
+	if i == 0 {
+		return 0, false
+	}
+	return rates[i-1].Rate, true
+}
+
+// Rate returns how many units of `to` one unit of `from` bought at the given time. Pairs without a
+// stored rate are converted through a currency both have a rate with.
+func (conv *Converter) Rate(from, to string, at time.Time) (float64, error) {
+	from, to = Normalize(from), Normalize(to)
+	if from == to {
+		return 1, nil
+	}
+	if rate, ok := conv.direct(from, to, at); ok {
+		return rate, nil
+	}
+
+	// Cross through the first currency, in alphabetical order, linked to both sides
+	for _, via := range conv.currencies {
+		if via == from || via == to {
+			continue
+		}
+		first, ok := conv.direct(from, via, at)
+		if !ok {
+			continue
+		}
+		second, ok := conv.direct(via, to, at)
+		if !ok {
+			continue
+		}
+		return first * second, nil
+	}
+
+	return 0, fmt.Errorf("no exchange rate from %s to %s on %s", from, to, at.Format("2006-01-02"))
+}
+
+// Convert converts an amount at the rate in effect at the given time
+func (conv *Converter) Convert(amount float64, from, to string, at time.Time) (float64, error) {
+	rate, err := conv.Rate(from, to, at)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
+
+// Sum converts an amount for inclusion in a total. Amounts without a usable rate count as zero and
+// their currency is remembered so callers can report it.
+func (conv *Converter) Sum(amount float64, from, to string, at time.Time) float64 {
+	if amount == 0 {
+		return 0
+	}
+	if Normalize(from) == "" {
+		conv.missing["(none)"] = true
+		return 0
+	}
+	converted, err := conv.Convert(amount, from, to, at)
+	if err != nil {
+		conv.missing[Normalize(from)] = true
+		return 0
+	}
+	return converted
+}
+
+// Unconverted lists the currencies Sum had to leave out
+func (conv *Converter) Unconverted() []string {
+	codes := make([]string, 0, len(conv.missing))
+	for code := range conv.missing {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,76 @@
+package exchangerate
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func testConverter() *Converter {
+	// Deliberately out of order; the converter sorts by effective date
+	return newConverter([]ExchangeRate{
+		{BaseCurrency: "USD", QuoteCurrency: "UGX", Rate: 3800, EffectiveFrom: date("2024-06-01")},
+		{BaseCurrency: "USD", QuoteCurrency: "UGX", Rate: 3700, EffectiveFrom: date("2024-01-01")},
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.1, EffectiveFrom: date("2024-01-01")},
+		{BaseCurrency: "KES", QuoteCurrency: "USD", Rate: 0.0077, EffectiveFrom: date("2024-03-01")},
+	})
+}
+
+func TestConverterRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		at       string
+		want     float64
+		wantErr  bool
+	}{
+		{"same currency", "UGX", "UGX", "2020-01-01", 1, false},
+		{"before the first rate", "USD", "UGX", "2023-12-31", 0, true},
+		{"on the effective date", "USD", "UGX", "2024-01-01", 3700, false},
+		{"day before a newer rate", "USD", "UGX", "2024-05-31", 3700, false},
+		{"newer rate takes over", "USD", "UGX", "2024-06-01", 3800, false},
+		{"lower case codes", "usd", " ugx ", "2024-06-01", 3800, false},
+		{"inverse of a stored rate", "UGX", "USD", "2024-07-01", 1.0 / 3800, false},
+		{"inverse before the newer rate", "UGX", "USD", "2024-02-01", 1.0 / 3700, false},
+		{"cross rate", "EUR", "UGX", "2024-07-01", 1.1 * 3800, false},
+		{"cross rate through inverses", "UGX", "EUR", "2024-07-01", 1 / 3800.0 / 1.1, false},
+		{"cross rate between two bases", "KES", "EUR", "2024-04-01", 0.0077 / 1.1, false},
+		{"cross rate with a leg not yet in effect", "KES", "UGX", "2024-02-01", 0, true},
+		{"cross rate once both legs are in effect", "KES", "UGX", "2024-04-01", 0.0077 * 3700, false},
+		{"unknown currency", "USD", "GBP", "2024-07-01", 0, true},
+	}
+	conv := testConverter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := conv.Rate(tt.from, tt.to, date(tt.at))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Rate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9*math.Max(1, tt.want) {
+				t.Errorf("Rate(%s, %s, %s) = %v, want %v", tt.from, tt.to, tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterSumRemembersMissingRates(t *testing.T) {
+	conv := testConverter()
+	at := date("2024-07-01")
+
+	total := conv.Sum(2, "USD", "UGX", at) + conv.Sum(100, "GBP", "UGX", at) + conv.Sum(5, "", "UGX", at) + conv.Sum(0, "JPY", "UGX", at)
+	if total != 7600 {
+		t.Errorf("total = %v, want 7600", total)
+	}
+	if got, want := conv.Unconverted(), []string{"(none)", "GBP"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unconverted() = %v, want %v", got, want)
+	}
+}
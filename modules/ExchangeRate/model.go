@@ -0,0 +1,19 @@
+package exchangerate
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRate says that one unit of BaseCurrency buys Rate units of QuoteCurrency from EffectiveFrom
+// until a later rate for the same pair takes over
+type ExchangeRate struct {
+	gorm.Model
+	BaseCurrency  string    `json:"baseCurrency" gorm:"uniqueIndex:idx_exchange_rate;size:3;not null"`
+	QuoteCurrency string    `json:"quoteCurrency" gorm:"uniqueIndex:idx_exchange_rate;size:3;not null"`
+	EffectiveFrom time.Time `json:"effectiveFrom" gorm:"uniqueIndex:idx_exchange_rate;not null"`
+	Rate          float64   `json:"rate" gorm:"not null"`
+	Source        string    `json:"source"` // manual or csv
+	CreatedByID   *uint     `json:"createdById"`
+}
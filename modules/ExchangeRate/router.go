@@ -0,0 +1,32 @@
+package exchangerate
+
+import (
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RegisterRoutes registers exchange rate routes
+func RegisterRoutes(r fiber.Router, db *gorm.DB) {
+	rates := r.Group("/exchange-rates", user.JWTProtect([]string{"*"}))
+
+	rates.Get("/", func(c *fiber.Ctx) error {
+		return GetAll(c, db)
+	})
+
+	rates.Get("/convert", func(c *fiber.Ctx) error {
+		return ConvertAmount(c, db)
+	})
+
+	rates.Post("/", func(c *fiber.Ctx) error {
+		return Create(c, db)
+	})
+
+	rates.Post("/import", func(c *fiber.Ctx) error {
+		return Import(c, db)
+	})
+
+	rates.Delete("/:id", func(c *fiber.Ctx) error {
+		return Delete(c, db)
+	})
+}
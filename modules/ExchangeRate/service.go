@@ -0,0 +1,237 @@
+package exchangerate
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// parseDate accepts a date (2006-01-02) or an RFC 3339 timestamp
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// validateRate checks a rate before it is stored
+func validateRate(rate *ExchangeRate) error {
+	rate.BaseCurrency = Normalize(rate.BaseCurrency)
+	rate.QuoteCurrency = Normalize(rate.QuoteCurrency)
+	if len(rate.BaseCurrency) != 3 || len(rate.QuoteCurrency) != 3 {
+		return fmt.Errorf("currencies must be 3-letter codes")
+	}
+	if rate.BaseCurrency == rate.QuoteCurrency {
+		return fmt.Errorf("base and quote currencies must differ")
+	}
+	if rate.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	return nil
+}
+
+// upsertRates stores rates, replacing any existing rate for the same pair and effective date
+func upsertRates(db *gorm.DB, rates []ExchangeRate) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "effective_from"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "created_by_id", "updated_at"}),
+	}).Create(&rates).Error
+}
+
+// GetAll lists exchange rates, optionally filtered by ?base= and ?quote=
+func GetAll(c *fiber.Ctx, db *gorm.DB) error {
+	query := db.Model(&ExchangeRate{})
+	if base := c.Query("base"); base != "" {
+		query = query.Where("base_currency = ?", Normalize(base))
+	}
+	if quote := c.Query("quote"); quote != "" {
+		query = query.Where("quote_currency = ?", Normalize(quote))
+	}
+
+	var rates []ExchangeRate
+	if err := query.Order("base_currency, quote_currency, effective_from DESC").Find(&rates).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get exchange rates: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": rates})
+}
+
+// Create stores a rate (super-admin action). A rate for the same pair and date is replaced.
+func Create(c *fiber.Ctx, db *gorm.DB) error {
+	if role, _ := c.Locals("role").(string); role != "super-admin" {
+		return c.Status(403).JSON(fiber.Map{"msg": "only super-admins can manage exchange rates"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	type RateRequest struct {
+		BaseCurrency  string  `json:"baseCurrency"`
+		QuoteCurrency string  `json:"quoteCurrency"`
+		Rate          float64 `json:"rate"`
+		EffectiveFrom string  `json:"effectiveFrom"`
+	}
+	var req RateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid exchange rate: " + err.Error()})
+	}
+
+	effectiveFrom, err := parseDate(req.EffectiveFrom)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "effectiveFrom must be a date (YYYY-MM-DD)"})
+	}
+	rate := ExchangeRate{
+		BaseCurrency:  req.BaseCurrency,
+		QuoteCurrency: req.QuoteCurrency,
+		Rate:          req.Rate,
+		EffectiveFrom: effectiveFrom,
+		Source:        "manual",
+		CreatedByID:   &userID,
+	}
+	if err := validateRate(&rate); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+	}
+
+	if err := upsertRates(db, []ExchangeRate{rate}); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to save exchange rate: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"msg": "exchange rate saved successfully", "data": rate})
+}
+
+// Import stores rates from an uploaded CSV file (super-admin action). The file needs a header row
+// with base, quote, rate and effective_from columns. Nothing is stored if any row is invalid.
+func Import(c *fiber.Ctx, db *gorm.DB) error {
+	if role, _ := c.Locals("role").(string); role != "super-admin" {
+		return c.Status(403).JSON(fiber.Map{"msg": "only super-admins can manage exchange rates"})
+	}
+	userID := c.Locals("user_id").(uint)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "a CSV file is required"})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to read file: " + err.Error()})
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to read CSV header: " + err.Error()})
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"base", "quote", "rate", "effective_from"} {
+		if _, ok := columns[name]; !ok {
+			return c.Status(400).JSON(fiber.Map{"msg": "CSV is missing the " + name + " column"})
+		}
+	}
+
+	var rates []ExchangeRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("line %d: %v", line, err)})
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[columns["rate"]]), 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("line %d: invalid rate", line)})
+		}
+		effectiveFrom, err := parseDate(record[columns["effective_from"]])
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("line %d: effective_from must be a date (YYYY-MM-DD)", line)})
+		}
+		rate := ExchangeRate{
+			BaseCurrency:  record[columns["base"]],
+			QuoteCurrency: record[columns["quote"]],
+			Rate:          value,
+			EffectiveFrom: effectiveFrom,
+			Source:        "csv",
+			CreatedByID:   &userID,
+		}
+		if err := validateRate(&rate); err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("line %d: %v", line, err)})
+		}
+		rates = append(rates, rate)
+	}
+	if len(rates) == 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "the CSV file has no rates"})
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return upsertRates(tx, rates)
+	}); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to import exchange rates: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"msg": fmt.Sprintf("%d exchange rates imported", len(rates))})
+}
+
+// Delete removes a rate (super-admin action)
+func Delete(c *fiber.Ctx, db *gorm.DB) error {
+	if role, _ := c.Locals("role").(string); role != "super-admin" {
+		return c.Status(403).JSON(fiber.Map{"msg": "only super-admins can manage exchange rates"})
+	}
+	var rate ExchangeRate
+	if err := db.First(&rate, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "exchange rate not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get exchange rate: " + err.Error()})
+	}
+
+	// Removed for good so the pair and date can be entered again
+	if err := db.Unscoped().Delete(&rate).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to delete exchange rate: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"msg": "exchange rate deleted successfully"})
+}
+
+// ConvertAmount converts ?amount= from ?from= to ?to= at the rate in effect on ?date= (default today)
+func ConvertAmount(c *fiber.Ctx, db *gorm.DB) error {
+	amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid amount"})
+	}
+	at := time.Now()
+	if date := c.Query("date"); date != "" {
+		if at, err = parseDate(date); err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "date must be a date (YYYY-MM-DD)"})
+		}
+	}
+
+	conv, err := NewConverter(db)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load exchange rates: " + err.Error()})
+	}
+	from, to := Normalize(c.Query("from")), ReportingCurrency(c.Query("to"))
+	rate, err := conv.Rate(from, to, at)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": fiber.Map{
+		"amount":    amount,
+		"from":      from,
+		"to":        to,
+		"rate":      rate,
+		"converted": amount * rate,
+	}})
+}
@@ -0,0 +1,467 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientFunds is returned when an account cannot cover a movement
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// post validates and stores a transaction
+func post(tx *gorm.DB, transaction *LedgerTransaction) error {
+	if err := validate(transaction); err != nil {
+		return err
+	}
+	return tx.Create(transaction).Error
+}
+
+// validate checks a transaction before it is stored and copies its project to the entries. Every entry
+// must be non-zero and the entries must balance in each currency.
+func validate(transaction *LedgerTransaction) error {
+	if len(transaction.Entries) < 2 {
+		return fmt.Errorf("a ledger transaction needs at least two entries")
+	}
+
+	totals := map[string]int64{}
+	for i := range transaction.Entries {
+		entry := &transaction.Entries[i]
+		if entry.Amount == 0 {
+			return fmt.Errorf("ledger entries cannot be zero")
+		}
+		if entry.Currency == "" {
+			return fmt.Errorf("ledger entries need a currency")
+		}
+		entry.ProjectID = transaction.ProjectID
+		totals[entry.Currency] += entry.Amount
+	}
+	for currency, total := range totals {
+		if total != 0 {
+			return fmt.Errorf("ledger transaction does not balance in %s", currency)
+		}
+	}
+	return nil
+}
+
+// actorPtr converts an actor ID to the nullable form stored on transactions
+func actorPtr(actorID uint) *uint {
+	if actorID == 0 {
+		return nil
+	}
+	return &actorID
+}
+
+// normalizeCurrency upper-cases a currency code
+func normalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// lockProject serializes ledger movements on a project for the rest of the transaction
+func lockProject(tx *gorm.DB, projectID uint) error {
+	var id uint
+	return tx.Table("projects").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", projectID).
+		Select("id").
+		Scan(&id).Error
+}
+
+// Balance returns the balance of one account in one currency
+func Balance(db *gorm.DB, accountType string, ownerID uint, currency string) (int64, error) {
+	var balance int64
+	err := db.Model(&LedgerEntry{}).
+		Where("account_type = ? AND owner_id = ? AND currency = ?", accountType, ownerID, normalizeCurrency(currency)).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
+
+// Balances returns the balance of an account in every currency it has used
+func Balances(db *gorm.DB, accountType string, ownerID uint) (map[string]int64, error) {
+	var rows []struct {
+		Currency string
+		Balance  int64
+	}
+	if err := db.Model(&LedgerEntry{}).
+		Select("currency, COALESCE(SUM(amount), 0) AS balance").
+		Where("account_type = ? AND owner_id = ?", accountType, ownerID).
+		Group("currency").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		balances[row.Currency] = row.Balance
+	}
+	return balances, nil
+}
+
+// Fund records a partner paying money into a project
+func Fund(tx *gorm.DB, projectID, partnerID uint, currency string, amount int64, actorID uint, memo string) (*LedgerTransaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("funding amount must be positive")
+	}
+	currency = normalizeCurrency(currency)
+
+	transaction := LedgerTransaction{
+		Kind:      KindFunding,
+		ProjectID: projectID,
+		ActorID:   actorPtr(actorID),
+		Memo:      memo,
+		Entries: []LedgerEntry{
+			{AccountType: AccountProject, OwnerID: projectID, Currency: currency, Amount: amount},
+			{AccountType: AccountPartner, OwnerID: partnerID, Currency: currency, Amount: -amount},
+		},
+	}
+	if err := post(tx, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// Hold moves a milestone's amount from the project's funds into escrow. Holding a milestone that is
+// already fully held does nothing. It must be called inside a transaction.
+func Hold(tx *gorm.DB, projectID, milestoneID uint, currency string, amount int64, actorID uint) error {
+	if amount <= 0 {
+		return nil
+	}
+	currency = normalizeCurrency(currency)
+
+	if err := lockProject(tx, projectID); err != nil {
+		return err
+	}
+
+	held, err := Balance(tx, AccountEscrow, milestoneID, currency)
+	if err != nil {
+		return err
+	}
+	needed := amount - held
+	if needed <= 0 {
+		return nil
+	}
+
+	available, err := Balance(tx, AccountProject, projectID, currency)
+	if err != nil {
+		return err
+	}
+	if available < needed {
+		return fmt.Errorf("%w: the project has %d %s available but the milestone needs %d", ErrInsufficientFunds, available, currency, needed)
+	}
+
+	return post(tx, &LedgerTransaction{
+		Kind:        KindHold,
+		ProjectID:   projectID,
+		MilestoneID: &milestoneID,
+		ActorID:     actorPtr(actorID),
+		Memo:        "milestone accepted",
+		Entries: []LedgerEntry{
+			{AccountType: AccountEscrow, OwnerID: milestoneID, Currency: currency, Amount: needed},
+			{AccountType: AccountProject, OwnerID: projectID, Currency: currency, Amount: -needed},
+		},
+	})
+}
+
+// Release pays everything held for a milestone out to the given students in equal shares; the first
+// payees receive any remainder. It must be called inside a transaction.
+func Release(tx *gorm.DB, projectID, milestoneID uint, payees []uint, actorID uint) error {
+	if len(payees) == 0 {
+		return fmt.Errorf("a release needs at least one payee")
+	}
+	if err := lockProject(tx, projectID); err != nil {
+		return err
+	}
+
+	held, err := Balances(tx, AccountEscrow, milestoneID)
+	if err != nil {
+		return err
+	}
+
+	for currency, amount := range held {
+		if amount <= 0 {
+			continue
+		}
+
+		entries := []LedgerEntry{{AccountType: AccountEscrow, OwnerID: milestoneID, Currency: currency, Amount: -amount}}
+		for i, paid := range shares(amount, len(payees)) {
+			if paid == 0 {
+				continue
+			}
+			entries = append(entries, LedgerEntry{AccountType: AccountStudent, OwnerID: payees[i], Currency: currency, Amount: paid})
+		}
+
+		if err := post(tx, &LedgerTransaction{
+			Kind:        KindRelease,
+			ProjectID:   projectID,
+			MilestoneID: &milestoneID,
+			ActorID:     actorPtr(actorID),
+			Memo:        "milestone released",
+			Entries:     entries,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shares splits an amount into n equal parts; the first parts receive any remainder
+func shares(amount int64, n int) []int64 {
+	parts := make([]int64, n)
+	share, remainder := amount/int64(n), amount%int64(n)
+	for i := range parts {
+		parts[i] = share
+		if int64(i) < remainder {
+			parts[i]++
+		}
+	}
+	return parts
+}
+
+// RefundMilestone returns everything held for a milestone to the project's funds.
+// It must be called inside a transaction.
+func RefundMilestone(tx *gorm.DB, projectID, milestoneID uint, actorID uint, memo string) error {
+	if err := lockProject(tx, projectID); err != nil {
+		return err
+	}
+
+	held, err := Balances(tx, AccountEscrow, milestoneID)
+	if err != nil {
+		return err
+	}
+
+	for currency, amount := range held {
+		if amount <= 0 {
+			continue
+		}
+		if err := post(tx, &LedgerTransaction{
+			Kind:        KindRefund,
+			ProjectID:   projectID,
+			MilestoneID: &milestoneID,
+			ActorID:     actorPtr(actorID),
+			Memo:        memo,
+			Entries: []LedgerEntry{
+				{AccountType: AccountProject, OwnerID: projectID, Currency: currency, Amount: amount},
+				{AccountType: AccountEscrow, OwnerID: milestoneID, Currency: currency, Amount: -amount},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefundProject returns every milestone escrow to the project and then all of the project's funds to
+// the partner. Used when a project is cancelled. It must be called inside a transaction.
+func RefundProject(tx *gorm.DB, projectID, partnerID uint, actorID uint) error {
+	if err := lockProject(tx, projectID); err != nil {
+		return err
+	}
+
+	var escrowIDs []uint
+	if err := tx.Model(&LedgerEntry{}).
+		Where("project_id = ? AND account_type = ?", projectID, AccountEscrow).
+		Distinct().
+		Pluck("owner_id", &escrowIDs).Error; err != nil {
+		return err
+	}
+	for _, milestoneID := range escrowIDs {
+		if err := RefundMilestone(tx, projectID, milestoneID, actorID, "project cancelled"); err != nil {
+			return err
+		}
+	}
+
+	available, err := Balances(tx, AccountProject, projectID)
+	if err != nil {
+		return err
+	}
+	for currency, amount := range available {
+		if amount <= 0 {
+			continue
+		}
+		if err := post(tx, &LedgerTransaction{
+			Kind:      KindRefund,
+			ProjectID: projectID,
+			ActorID:   actorPtr(actorID),
+			Memo:      "project cancelled",
+			Entries: []LedgerEntry{
+				{AccountType: AccountPartner, OwnerID: partnerID, Currency: currency, Amount: amount},
+				{AccountType: AccountProject, OwnerID: projectID, Currency: currency, Amount: -amount},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockUser serializes ledger movements on a student's accounts for the rest of the transaction
+func lockUser(tx *gorm.DB, userID uint) error {
+	var id uint
+	return tx.Table("users").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", userID).
+		Select("id").
+		Scan(&id).Error
+}
+
+// Payout moves money a student has earned out of their balance before it is sent to them.
+// It must be called inside a transaction.
+func Payout(tx *gorm.DB, studentID uint, currency string, amount int64, actorID uint, memo string) (*LedgerTransaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("payout amount must be positive")
+	}
+	currency = normalizeCurrency(currency)
+	if err := lockUser(tx, studentID); err != nil {
+		return nil, err
+	}
+
+	available, err := Balance(tx, AccountStudent, studentID, currency)
+	if err != nil {
+		return nil, err
+	}
+	if available < amount {
+		return nil, ErrInsufficientFunds
+	}
+
+	transaction := LedgerTransaction{
+		Kind:    KindPayout,
+		ActorID: actorPtr(actorID),
+		Memo:    memo,
+		Entries: []LedgerEntry{
+			{AccountType: AccountPaidOut, OwnerID: studentID, Currency: currency, Amount: amount},
+			{AccountType: AccountStudent, OwnerID: studentID, Currency: currency, Amount: -amount},
+		},
+	}
+	if err := post(tx, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// ReversePayout returns a failed payout to the student's balance. It must be called inside a transaction.
+func ReversePayout(tx *gorm.DB, studentID uint, currency string, amount int64, memo string) error {
+	currency = normalizeCurrency(currency)
+	if err := lockUser(tx, studentID); err != nil {
+		return err
+	}
+	return post(tx, &LedgerTransaction{
+		Kind: KindPayoutReversal,
+		Memo: memo,
+		Entries: []LedgerEntry{
+			{AccountType: AccountStudent, OwnerID: studentID, Currency: currency, Amount: amount},
+			{AccountType: AccountPaidOut, OwnerID: studentID, Currency: currency, Amount: -amount},
+		},
+	})
+}
+
+// Earnings returns everything released to a student per currency, whether or not it has been paid out
+func Earnings(db *gorm.DB, studentID uint) (map[string]int64, error) {
+	earnings, err := Balances(db, AccountStudent, studentID)
+	if err != nil {
+		return nil, err
+	}
+	paidOut, err := Balances(db, AccountPaidOut, studentID)
+	if err != nil {
+		return nil, err
+	}
+	for currency, amount := range paidOut {
+		earnings[currency] += amount
+	}
+	return earnings, nil
+}
+
+// Payment is an amount in one currency
+type Payment struct {
+	Currency string
+	Amount   int64
+}
+
+// studentReleases sums what milestone releases paid each student, for the releases matching the filter
+func studentReleases(db *gorm.DB, column string, id uint) (map[uint][]Payment, error) {
+	var rows []struct {
+		OwnerID  uint
+		Currency string
+		Total    int64
+	}
+	if err := db.Model(&LedgerEntry{}).
+		Select("ledger_entries.owner_id, ledger_entries.currency, SUM(ledger_entries.amount) AS total").
+		Joins("JOIN ledger_transactions ON ledger_transactions.id = ledger_entries.transaction_id").
+		Where("ledger_transactions.kind = ? AND ledger_entries.account_type = ?", KindRelease, AccountStudent).
+		Where("ledger_transactions."+column+" = ?", id).
+		Group("ledger_entries.owner_id, ledger_entries.currency").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	paid := map[uint][]Payment{}
+	for _, row := range rows {
+		paid[row.OwnerID] = append(paid[row.OwnerID], Payment{Currency: row.Currency, Amount: row.Total})
+	}
+	return paid, nil
+}
+
+// MilestoneReleases returns what the release of a milestone paid each student
+func MilestoneReleases(db *gorm.DB, milestoneID uint) (map[uint][]Payment, error) {
+	return studentReleases(db, "milestone_id", milestoneID)
+}
+
+// ProjectReleases returns what milestone releases on a project paid each student
+func ProjectReleases(db *gorm.DB, projectID uint) (map[uint][]Payment, error) {
+	return studentReleases(db, "project_id", projectID)
+}
+
+// Summary totals the money that has moved through a set of projects, per currency
+type Summary struct {
+	Funded    int64 `json:"funded"`    // Paid in by partners
+	Available int64 `json:"available"` // Funded but not held for a milestone
+	InEscrow  int64 `json:"inEscrow"`  // Held for milestones
+	Released  int64 `json:"released"`  // Paid out to students
+	Refunded  int64 `json:"refunded"`  // Returned to partners
+}
+
+// ProjectSummaries totals the ledger activity of the given projects, keyed by currency
+func ProjectSummaries(db *gorm.DB, projectIDs []uint) (map[string]Summary, error) {
+	summaries := map[string]Summary{}
+	if len(projectIDs) == 0 {
+		return summaries, nil
+	}
+
+	var rows []struct {
+		Kind        string
+		AccountType string
+		Currency    string
+		Total       int64
+	}
+	if err := db.Model(&LedgerEntry{}).
+		Select("ledger_transactions.kind, ledger_entries.account_type, ledger_entries.currency, COALESCE(SUM(ledger_entries.amount), 0) AS total").
+		Joins("JOIN ledger_transactions ON ledger_transactions.id = ledger_entries.transaction_id").
+		Where("ledger_entries.project_id IN ?", projectIDs).
+		Group("ledger_transactions.kind, ledger_entries.account_type, ledger_entries.currency").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		s := summaries[row.Currency]
+		switch row.AccountType {
+		case AccountPartner:
+			if row.Kind == KindFunding {
+				s.Funded -= row.Total
+			} else if row.Kind == KindRefund {
+				s.Refunded += row.Total
+			}
+		case AccountProject:
+			s.Available += row.Total
+		case AccountEscrow:
+			s.InEscrow += row.Total
+		case AccountStudent:
+			s.Released += row.Total
+		}
+		summaries[row.Currency] = s
+	}
+	return summaries, nil
+}
@@ -0,0 +1,191 @@
+package ledger
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func entry(accountType string, ownerID uint, currency string, amount int64) LedgerEntry {
+	return LedgerEntry{AccountType: accountType, OwnerID: ownerID, Currency: currency, Amount: amount}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []LedgerEntry
+		wantErr bool
+	}{
+		{"balanced", []LedgerEntry{entry(AccountProject, 1, "USD", 100), entry(AccountPartner, 2, "USD", -100)}, false},
+		{"balanced split", []LedgerEntry{entry(AccountEscrow, 1, "USD", -100), entry(AccountStudent, 2, "USD", 51), entry(AccountStudent, 3, "USD", 49)}, false},
+		{"balanced in each currency", []LedgerEntry{
+			entry(AccountProject, 1, "USD", 100), entry(AccountPartner, 2, "USD", -100),
+			entry(AccountProject, 1, "UGX", 5000), entry(AccountPartner, 2, "UGX", -5000),
+		}, false},
+		{"unbalanced", []LedgerEntry{entry(AccountProject, 1, "USD", 100), entry(AccountPartner, 2, "USD", -90)}, true},
+		{"balanced only across currencies", []LedgerEntry{entry(AccountProject, 1, "USD", 100), entry(AccountPartner, 2, "UGX", -100)}, true},
+		{"single entry", []LedgerEntry{entry(AccountProject, 1, "USD", 0)}, true},
+		{"zero entry", []LedgerEntry{entry(AccountProject, 1, "USD", 0), entry(AccountPartner, 2, "USD", 0)}, true},
+		{"missing currency", []LedgerEntry{entry(AccountProject, 1, "", 100), entry(AccountPartner, 2, "", -100)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction := LedgerTransaction{ProjectID: 9, Entries: tt.entries}
+			err := validate(&transaction)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				for _, e := range transaction.Entries {
+					if e.ProjectID != 9 {
+						t.Errorf("entry project = %d, want the transaction's project", e.ProjectID)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestShares(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		n      int
+		want   []int64
+	}{
+		{"even", 300, 3, []int64{100, 100, 100}},
+		{"remainder to first payees", 302, 3, []int64{101, 101, 100}},
+		{"single payee", 7, 1, []int64{7}},
+		{"less than one each", 2, 3, []int64{1, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shares(tt.amount, tt.n)
+			var total int64
+			for i := range got {
+				total += got[i]
+				if got[i] != tt.want[i] {
+					t.Errorf("shares(%d, %d) = %v, want %v", tt.amount, tt.n, got, tt.want)
+					break
+				}
+			}
+			if total != tt.amount {
+				t.Errorf("shares sum to %d, want %d", total, tt.amount)
+			}
+		})
+	}
+}
+
+// testTx opens DATABASE_URL and returns a transaction with empty ledger tables that is rolled back when
+// the test ends. Tests using it are skipped when DATABASE_URL is unset.
+func testTx(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+
+	// Temporary tables shadow any real ones for the rest of the transaction
+	for _, stmt := range []string{
+		"CREATE TEMP TABLE projects (id bigint PRIMARY KEY) ON COMMIT DROP",
+		"CREATE TEMP TABLE users (id bigint PRIMARY KEY) ON COMMIT DROP",
+		"CREATE TEMP TABLE ledger_transactions (LIKE public.ledger_transactions INCLUDING ALL) ON COMMIT DROP",
+		"CREATE TEMP TABLE ledger_entries (LIKE public.ledger_entries INCLUDING ALL) ON COMMIT DROP",
+	} {
+		if err := tx.Exec(stmt).Error; err != nil {
+			t.Skipf("database is not migrated: %v", err)
+		}
+	}
+	return tx
+}
+
+// assertBalanced checks the double-entry invariant: all entries sum to zero in every currency
+func assertBalanced(t *testing.T, tx *gorm.DB) {
+	t.Helper()
+	var unbalanced []string
+	if err := tx.Model(&LedgerEntry{}).Group("currency").Having("SUM(amount) <> 0").Pluck("currency", &unbalanced).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(unbalanced) > 0 {
+		t.Errorf("ledger does not balance in %v", unbalanced)
+	}
+}
+
+func assertBalance(t *testing.T, tx *gorm.DB, accountType string, ownerID uint, want int64) {
+	t.Helper()
+	got, err := Balance(tx, accountType, ownerID, "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("%s %d balance = %d, want %d", accountType, ownerID, got, want)
+	}
+}
+
+func TestEscrowFlow(t *testing.T) {
+	tx := testTx(t)
+	const project, partner, milestone, otherMilestone = 1, 2, 10, 11
+	students := []uint{20, 21, 22}
+
+	if _, err := Fund(tx, project, partner, "usd", 1000, partner, "funding"); err != nil {
+		t.Fatalf("Fund() error = %v", err)
+	}
+	assertBalance(t, tx, AccountProject, project, 1000)
+	assertBalance(t, tx, AccountPartner, partner, -1000)
+
+	// Holding is idempotent and only tops up to the milestone amount
+	for i := 0; i < 2; i++ {
+		if err := Hold(tx, project, milestone, "USD", 601, partner); err != nil {
+			t.Fatalf("Hold() error = %v", err)
+		}
+	}
+	assertBalance(t, tx, AccountEscrow, milestone, 601)
+	assertBalance(t, tx, AccountProject, project, 399)
+
+	if err := Hold(tx, project, otherMilestone, "USD", 400, partner); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("Hold() beyond the available funds error = %v, want ErrInsufficientFunds", err)
+	}
+
+	if err := Release(tx, project, milestone, students, partner); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	assertBalance(t, tx, AccountEscrow, milestone, 0)
+	assertBalance(t, tx, AccountStudent, students[0], 201)
+	assertBalance(t, tx, AccountStudent, students[1], 200)
+	assertBalance(t, tx, AccountStudent, students[2], 200)
+
+	if _, err := Payout(tx, students[0], "USD", 202, students[0], "too much"); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("Payout() beyond the balance error = %v, want ErrInsufficientFunds", err)
+	}
+	if _, err := Payout(tx, students[0], "USD", 150, students[0], "payout"); err != nil {
+		t.Fatalf("Payout() error = %v", err)
+	}
+	if err := ReversePayout(tx, students[0], "USD", 150, "payout failed"); err != nil {
+		t.Fatalf("ReversePayout() error = %v", err)
+	}
+	assertBalance(t, tx, AccountStudent, students[0], 201)
+	assertBalance(t, tx, AccountPaidOut, students[0], 0)
+
+	if err := Hold(tx, project, otherMilestone, "USD", 300, partner); err != nil {
+		t.Fatalf("Hold() error = %v", err)
+	}
+	if err := RefundProject(tx, project, partner, partner); err != nil {
+		t.Fatalf("RefundProject() error = %v", err)
+	}
+	assertBalance(t, tx, AccountEscrow, otherMilestone, 0)
+	assertBalance(t, tx, AccountProject, project, 0)
+	assertBalance(t, tx, AccountPartner, partner, -601)
+
+	assertBalanced(t, tx)
+}
@@ -0,0 +1,46 @@
+package ledger
+
+import "gorm.io/gorm"
+
+// Account types. An account is identified by its type, owner and currency.
+const (
+	AccountPartner = "partner"  // Owner is the partner's user ID; credited when they fund a project, debited on refunds
+	AccountProject = "project"  // Owner is the project ID; funded money not yet held for a milestone
+	AccountEscrow  = "escrow"   // Owner is the milestone ID; money held until the milestone is released or refunded
+	AccountStudent = "student"  // Owner is the student's user ID; money released to them and not yet paid out
+	AccountPaidOut = "paid-out" // Owner is the student's user ID; money sent to them through a payment provider
+)
+
+// Transaction kinds
+const (
+	KindFunding = "FUNDING" // partner -> project
+	KindHold    = "HOLD"    // project -> milestone escrow
+	KindRelease = "RELEASE" // milestone escrow -> students
+	KindRefund  = "REFUND"  // milestone escrow -> project, or project -> partner
+
+	KindPayout         = "PAYOUT"          // student -> paid out
+	KindPayoutReversal = "PAYOUT_REVERSAL" // paid out -> student, when the provider fails a payout
+)
+
+// LedgerTransaction groups the entries of a single money movement
+type LedgerTransaction struct {
+	gorm.Model
+	Kind        string        `json:"kind" gorm:"index;not null"`
+	ProjectID   uint          `json:"projectId" gorm:"index;not null"` // 0 for payouts, which are not tied to a project
+	MilestoneID *uint         `json:"milestoneId" gorm:"index"`
+	ActorID     *uint         `json:"actorId"` // Nil for system-driven movements
+	Memo        string        `json:"memo"`
+	Entries     []LedgerEntry `json:"entries" gorm:"foreignKey:TransactionID"`
+}
+
+// LedgerEntry is one side of a transaction. Debits are positive and credits negative; the entries of
+// a transaction sum to zero in every currency.
+type LedgerEntry struct {
+	gorm.Model
+	TransactionID uint   `json:"transactionId" gorm:"index;not null"`
+	ProjectID     uint   `json:"projectId" gorm:"index;not null"` // Copied from the transaction for reporting
+	AccountType   string `json:"accountType" gorm:"index:idx_ledger_account;not null"`
+	OwnerID       uint   `json:"ownerId" gorm:"index:idx_ledger_account;not null"`
+	Currency      string `json:"currency" gorm:"index:idx_ledger_account;not null"`
+	Amount        int64  `json:"amount"`
+}
@@ -0,0 +1,32 @@
+package ledger
+
+import (
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RegisterRoutes registers ledger routes
+func RegisterRoutes(r fiber.Router, db *gorm.DB) {
+	ledger := r.Group("/ledger", user.JWTProtect([]string{"partner", "student", "super-admin"}))
+
+	ledger.Post("/fund", func(c *fiber.Ctx) error {
+		return RecordFunding(c, db)
+	})
+
+	ledger.Get("/projects/:id", func(c *fiber.Ctx) error {
+		return GetProjectBalance(c, db)
+	})
+
+	ledger.Get("/projects/:id/transactions", func(c *fiber.Ctx) error {
+		return GetTransactions(c, db)
+	})
+
+	ledger.Get("/partners/:id", func(c *fiber.Ctx) error {
+		return GetPartnerBalance(c, db)
+	})
+
+	ledger.Get("/students/:id", func(c *fiber.Ctx) error {
+		return GetStudentBalance(c, db)
+	})
+}
@@ -0,0 +1,185 @@
+package ledger
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// projectOwner returns the partner who owns a project
+func projectOwner(db *gorm.DB, projectID uint) (uint, error) {
+	var owner struct {
+		UserID uint
+	}
+	err := db.Table("projects").
+		Where("id = ? AND deleted_at IS NULL", projectID).
+		Select("user_id").
+		Take(&owner).Error
+	return owner.UserID, err
+}
+
+// parseID reads a numeric route parameter
+func parseID(c *fiber.Ctx, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Params(name), 10, 32)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// RecordFunding records money a partner has paid into a project (super-admin action, e.g. after a bank transfer clears)
+func RecordFunding(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	if role != "super-admin" {
+		return c.Status(403).JSON(fiber.Map{"msg": "only super-admins can record project funding"})
+	}
+
+	type FundRequest struct {
+		ProjectID uint   `json:"projectId"`
+		Amount    int64  `json:"amount"`
+		Currency  string `json:"currency"`
+		Memo      string `json:"memo"`
+	}
+	var req FundRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid funding data: " + err.Error()})
+	}
+	if req.ProjectID == 0 || req.Currency == "" {
+		return c.Status(400).JSON(fiber.Map{"msg": "projectId and currency are required"})
+	}
+
+	partnerID, err := projectOwner(db, req.ProjectID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "project not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get project: " + err.Error()})
+	}
+
+	var transaction *LedgerTransaction
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transaction, err = Fund(tx, req.ProjectID, partnerID, req.Currency, req.Amount, userID, req.Memo)
+		return err
+	}); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to record funding: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"msg": "funding recorded successfully", "data": transaction})
+}
+
+// GetProjectBalance returns the ledger summary of a project (project owner or super-admin)
+func GetProjectBalance(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	projectID, ok := parseID(c, "id")
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid project id"})
+	}
+
+	ownerID, err := projectOwner(db, projectID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "project not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get project: " + err.Error()})
+	}
+	if role != "super-admin" && ownerID != userID {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to view this project's balance"})
+	}
+
+	summaries, err := ProjectSummaries(db, []uint{projectID})
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get project balance: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": summaries})
+}
+
+// GetPartnerBalance returns the ledger summary across every project of a partner (the partner or a super-admin)
+func GetPartnerBalance(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	partnerID, ok := parseID(c, "id")
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid partner id"})
+	}
+	if role != "super-admin" && partnerID != userID {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to view this partner's balance"})
+	}
+
+	var projectIDs []uint
+	if err := db.Table("projects").Where("user_id = ?", partnerID).Pluck("id", &projectIDs).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get projects: " + err.Error()})
+	}
+
+	summaries, err := ProjectSummaries(db, projectIDs)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get partner balance: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": summaries})
+}
+
+// GetStudentBalance returns what a student can still withdraw and what has been paid out, per currency
+// (the student or a super-admin)
+func GetStudentBalance(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	studentID, ok := parseID(c, "id")
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid student id"})
+	}
+	if role != "super-admin" && studentID != userID {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to view this student's balance"})
+	}
+
+	available, err := Balances(db, AccountStudent, studentID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get student balance: " + err.Error()})
+	}
+	paidOut, err := Balances(db, AccountPaidOut, studentID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get student balance: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": fiber.Map{"available": available, "paidOut": paidOut}})
+}
+
+// GetTransactions lists the ledger transactions of a project (project owner or super-admin)
+func GetTransactions(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	projectID, ok := parseID(c, "id")
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid project id"})
+	}
+
+	ownerID, err := projectOwner(db, projectID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "project not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get project: " + err.Error()})
+	}
+	if role != "super-admin" && ownerID != userID {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to view this project's transactions"})
+	}
+
+	var transactions []LedgerTransaction
+	if err := db.Where("project_id = ?", projectID).
+		Preload("Entries").
+		Order("created_at ASC").
+		Find(&transactions).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get transactions: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": transactions})
+}
@@ -0,0 +1,53 @@
+package milestone
+
+import (
+	"fmt"
+	"strings"
+
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"gorm.io/gorm"
+)
+
+// checkAllocation verifies that a milestone amount fits in what is left of its project's budget and
+// is in the budget currency. milestoneID is the milestone being updated, or 0 for a new one. The
+// project row is locked so concurrent milestone changes cannot overspend the budget; it must be
+// called inside a transaction.
+func checkAllocation(tx *gorm.DB, projectID uint, currency string, amount int, milestoneID uint) error {
+	if amount < 0 {
+		return fmt.Errorf("milestone amount cannot be negative")
+	}
+
+	proj, err := project.LockForAssignment(tx, projectID)
+	if err != nil {
+		return err
+	}
+	budgetCurrency := proj.BudgetCurrency()
+	if budgetCurrency == "" {
+		return fmt.Errorf("the project has no budget currency")
+	}
+	if strings.ToUpper(strings.TrimSpace(currency)) != budgetCurrency {
+		return fmt.Errorf("milestone currency must match the project budget currency (%s)", budgetCurrency)
+	}
+
+	summary, err := project.GetBudgetSummary(tx, proj)
+	if err != nil {
+		return err
+	}
+
+	// Leave the milestone's current amount out when it is being updated
+	allocated := summary.Allocated
+	if milestoneID != 0 {
+		var current Milestone
+		if err := tx.First(&current, milestoneID).Error; err != nil {
+			return err
+		}
+		if strings.EqualFold(current.Currency, budgetCurrency) {
+			allocated -= int64(current.Amount)
+		}
+	}
+
+	if allocated+int64(amount) > summary.Budget {
+		return fmt.Errorf("milestone amount exceeds the remaining project budget of %d %s", summary.Budget-allocated, budgetCurrency)
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package milestone
 
 import (
+	"time"
+
 	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -12,8 +16,69 @@ type Milestone struct {
 	Title              string          `json:"title"`
 	Scope              string          `json:"scope"`
 	AcceptanceCriteria string          `json:"acceptanceCriteria"`
-	DueDate            string          `json:"dueDate"`
+	DueDate            *time.Time      `json:"dueDate" gorm:"type:date;index"`
 	Amount             int             `json:"amount"`
 	Currency           string          `json:"currency"`
-	Status             string          `json:"status"`
+	Status             string          `json:"status"`                 // PROPOSED, ACCEPTED, IN_PROGRESS, SUBMITTED, CHANGES_REQUESTED, APPROVED, RELEASED
+	OverdueAt          *time.Time      `json:"overdueAt" gorm:"index"` // Set by the overdue job; cleared on approval or when the due date moves
+	EscalatedAt        *time.Time      `json:"escalatedAt"`            // Set when an overdue milestone is escalated to the university admin
+}
+
+// MilestoneEvent records a single status change on a milestone
+type MilestoneEvent struct {
+	gorm.Model
+	MilestoneID uint       `json:"milestoneId" gorm:"index;not null"`
+	ActorID     *uint      `json:"actorId"` // Nil for system-driven transitions
+	Actor       *user.User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	ActorRole   string     `json:"actorRole"` // student, partner, supervisor or super-admin
+	FromStatus  string     `json:"fromStatus"`
+	ToStatus    string     `json:"toStatus"`
+	Note        string     `json:"note" gorm:"type:text"`
+}
+
+// MilestoneSubmission is one revision of the work delivered for a milestone
+type MilestoneSubmission struct {
+	gorm.Model
+	MilestoneID   uint                `json:"milestoneId" gorm:"uniqueIndex:idx_milestone_revision;not null"`
+	Revision      int                 `json:"revision" gorm:"uniqueIndex:idx_milestone_revision;not null"` // 1 for the first submission, 2+ for rework
+	SubmittedByID uint                `json:"submittedById" gorm:"not null"`
+	SubmittedBy   user.User           `json:"submittedBy" gorm:"foreignKey:SubmittedByID"`
+	GroupID       *uint               `json:"groupId" gorm:"index"` // Group of the submitting student's ASSIGNED application
+	Notes         string              `json:"notes" gorm:"type:text"`
+	Files         datatypes.JSON      `json:"files" gorm:"type:json"` // Array of file paths from /milestones/upload
+	Comments      []SubmissionComment `json:"comments,omitempty" gorm:"foreignKey:SubmissionID"`
+}
+
+// SubmissionComment is a review comment on a submission. Replies point at their parent comment.
+type SubmissionComment struct {
+	gorm.Model
+	SubmissionID uint      `json:"submissionId" gorm:"index;not null"`
+	ParentID     *uint     `json:"parentId"`
+	AuthorID     uint      `json:"authorId" gorm:"not null"`
+	Author       user.User `json:"author" gorm:"foreignKey:AuthorID"`
+	AuthorRole   string    `json:"authorRole"` // student, partner, supervisor or super-admin
+	Body         string    `json:"body" gorm:"type:text"`
+}
+
+// MilestoneTemplate is a reusable milestone plan owned by an organization, e.g. "12-week software build"
+type MilestoneTemplate struct {
+	gorm.Model
+	OrganizationID uint                    `json:"organizationId" gorm:"index;not null"`
+	Name           string                  `json:"name" gorm:"not null"`
+	Description    string                  `json:"description" gorm:"type:text"`
+	CreatedByID    uint                    `json:"createdById"`
+	Items          []MilestoneTemplateItem `json:"items" gorm:"foreignKey:TemplateID"`
+}
+
+// MilestoneTemplateItem is one milestone of a template. Its due date and amount are relative to the
+// project it is applied to.
+type MilestoneTemplateItem struct {
+	gorm.Model
+	TemplateID         uint    `json:"templateId" gorm:"index;not null"`
+	Position           int     `json:"position"`
+	Title              string  `json:"title" gorm:"not null"`
+	Scope              string  `json:"scope" gorm:"type:text"`
+	AcceptanceCriteria string  `json:"acceptanceCriteria" gorm:"type:text"`
+	DueOffsetDays      int     `json:"dueOffsetDays"` // Days after the start date
+	BudgetPercent      float64 `json:"budgetPercent"` // Share of the project budget
 }
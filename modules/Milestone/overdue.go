@@ -0,0 +1,192 @@
+package milestone
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	notification "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Notification"
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"gorm.io/gorm"
+)
+
+// Defaults used when OVERDUE_SWEEP_INTERVAL_MINUTES or OVERDUE_ESCALATION_GRACE_DAYS are unset or invalid
+const (
+	defaultOverdueSweepIntervalMinutes = 60
+	defaultOverdueEscalationGraceDays  = 3
+)
+
+// dueDateLayouts are the formats a milestone due date is accepted in
+var dueDateLayouts = []string{"2006-01-02", time.RFC3339, "2006-01-02T15:04:05.000Z"}
+
+// ParseDueDate parses a due date sent by a client. Only the calendar date is kept; an empty value means
+// the milestone has no due date.
+func ParseDueDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range dueDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+			return &date, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid due date %q. Use a date such as 2006-01-02", value)
+}
+
+// MigrateDueDates converts the due_date column from the text it used to be to a date column. Values
+// that are not dates are cleared. Call it before AutoMigrate.
+func MigrateDueDates(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&Milestone{}) {
+		return nil
+	}
+	columns, err := db.Migrator().ColumnTypes(&Milestone{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if column.Name() != "due_date" {
+			continue
+		}
+		if kind := strings.ToLower(column.DatabaseTypeName()); kind != "text" && kind != "varchar" {
+			return nil
+		}
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(`UPDATE milestones SET due_date = NULL WHERE due_date !~ '^[0-9]{4}-[0-9]{2}-[0-9]{2}'`).Error; err != nil {
+				return err
+			}
+			return tx.Exec(`ALTER TABLE milestones ALTER COLUMN due_date TYPE date USING substring(due_date from 1 for 10)::date`).Error
+		})
+	}
+	return nil
+}
+
+// overdueSweepInterval returns how often milestones are checked for missed due dates
+func overdueSweepInterval() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("OVERDUE_SWEEP_INTERVAL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = defaultOverdueSweepIntervalMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// overdueEscalationGrace returns how long a milestone may stay overdue before the university admin is told
+func overdueEscalationGrace() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("OVERDUE_ESCALATION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		days = defaultOverdueEscalationGraceDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// doneStatuses are the statuses at which a milestone can no longer be overdue
+var doneStatuses = []string{StatusApproved, StatusReleased}
+
+// universityAdminIDs returns the admin of the university hosting a project and its active delegated admins
+func universityAdminIDs(db *gorm.DB, departmentID int) []uint {
+	var orgID uint
+	db.Table("departments").Where("id = ?", departmentID).Select("organization_id").Scan(&orgID)
+	if orgID == 0 {
+		return nil
+	}
+
+	var adminIDs []uint
+	db.Table("organizations").Where("id = ?", orgID).Pluck("user_id", &adminIDs)
+	var delegated []uint
+	db.Table("delegated_accesses").
+		Where("organization_id = ? AND is_active = ?", orgID, true).
+		Pluck("delegated_user_id", &delegated)
+	return append(adminIDs, delegated...)
+}
+
+// flagOverdueMilestones marks milestones whose due date has passed and notifies the assigned students,
+// the supervisor and the partner. Each milestone is flagged once.
+func flagOverdueMilestones(db *gorm.DB, now time.Time) {
+	today := now.UTC().Format("2006-01-02")
+
+	var milestones []Milestone
+	if err := db.Preload("Project").
+		Where("due_date < ? AND overdue_at IS NULL AND status NOT IN ?", today, doneStatuses).
+		Find(&milestones).Error; err != nil {
+		log.Printf("Overdue scheduler: failed to find overdue milestones: %v", err)
+		return
+	}
+
+	for _, ms := range milestones {
+		// Skip the milestone if another run flagged it in the meantime
+		result := db.Model(&Milestone{}).
+			Where("id = ? AND overdue_at IS NULL", ms.ID).
+			Update("overdue_at", now)
+		if result.Error != nil {
+			log.Printf("Overdue scheduler: failed to flag milestone %d: %v", ms.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		recipients, err := project.AssignedStudentIDs(db, ms.ProjectID)
+		if err != nil {
+			log.Printf("Overdue scheduler: failed to find the students on milestone %d: %v", ms.ID, err)
+		}
+		recipients = append(recipients, ms.Project.UserID)
+		if ms.Project.SupervisorID != nil {
+			recipients = append(recipients, *ms.Project.SupervisorID)
+		}
+		message := fmt.Sprintf("Milestone \"%s\" on \"%s\" was due on %s and is not yet approved.", ms.Title, ms.Project.Title, ms.DueDate.Format("2006-01-02"))
+		if err := notification.Notify(db, recipients, "milestone_overdue", "Milestone Overdue", message, fmt.Sprintf("/milestones/%d", ms.ID)); err != nil {
+			log.Printf("Overdue scheduler: failed to notify about milestone %d: %v", ms.ID, err)
+		}
+	}
+}
+
+// escalateOverdueMilestones tells the university admin about milestones still overdue after the grace period
+func escalateOverdueMilestones(db *gorm.DB, now time.Time) {
+	var milestones []Milestone
+	if err := db.Preload("Project").
+		Where("overdue_at IS NOT NULL AND overdue_at < ? AND escalated_at IS NULL AND status NOT IN ?", now.Add(-overdueEscalationGrace()), doneStatuses).
+		Find(&milestones).Error; err != nil {
+		log.Printf("Overdue scheduler: failed to find milestones to escalate: %v", err)
+		return
+	}
+
+	for _, ms := range milestones {
+		result := db.Model(&Milestone{}).
+			Where("id = ? AND escalated_at IS NULL", ms.ID).
+			Update("escalated_at", now)
+		if result.Error != nil {
+			log.Printf("Overdue scheduler: failed to escalate milestone %d: %v", ms.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		admins := universityAdminIDs(db, ms.Project.DepartmentID)
+		if len(admins) == 0 {
+			continue
+		}
+		message := fmt.Sprintf("Milestone \"%s\" on \"%s\" has been overdue since %s and still needs attention.", ms.Title, ms.Project.Title, ms.DueDate.Format("2006-01-02"))
+		if err := notification.Notify(db, admins, "milestone_escalated", "Overdue Milestone Escalated", message, fmt.Sprintf("/milestones/%d", ms.ID)); err != nil {
+			log.Printf("Overdue scheduler: failed to notify admins about milestone %d: %v", ms.ID, err)
+		}
+	}
+}
+
+// StartOverdueScheduler starts the background job that flags and escalates overdue milestones (call this from main.go)
+func StartOverdueScheduler(db *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(overdueSweepInterval())
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			flagOverdueMilestones(db, now)
+			escalateOverdueMilestones(db, now)
+			<-ticker.C
+		}
+	}()
+}
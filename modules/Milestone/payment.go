@@ -0,0 +1,103 @@
+package milestone
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
+	portfolio "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Portfolio"
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"gorm.io/gorm"
+)
+
+// milestonePayees returns the students paid when a milestone is released: the group behind its
+// latest submission, or every student assigned to the project if nothing was submitted
+func milestonePayees(tx *gorm.DB, milestone Milestone) ([]uint, error) {
+	var payees []uint
+
+	var latest MilestoneSubmission
+	err := tx.Where("milestone_id = ?", milestone.ID).Order("revision DESC").First(&latest).Error
+	if err == nil && latest.GroupID != nil {
+		tx.Table("user_groups").Where("group_id = ?", *latest.GroupID).Order("user_id").Pluck("user_id", &payees)
+		if len(payees) > 0 {
+			return payees, nil
+		}
+	}
+
+	return project.AssignedStudentIDs(tx, milestone.ProjectID)
+}
+
+// settleFunds moves money in the ledger for a status change: accepting a milestone puts its amount
+// in escrow and releasing it pays the escrow out to the students. It must run in the same
+// transaction as the status change.
+func settleFunds(tx *gorm.DB, milestone Milestone, newStatus string, actorID uint) error {
+	switch newStatus {
+	case StatusAccepted:
+		if milestone.Amount <= 0 {
+			return nil
+		}
+		err := ledger.Hold(tx, milestone.ProjectID, milestone.ID, milestone.Currency, int64(milestone.Amount), actorID)
+		if errors.Is(err, ledger.ErrInsufficientFunds) {
+			return fmt.Errorf("the project does not have enough funds to cover this milestone")
+		}
+		return err
+	case StatusReleased:
+		payees, err := milestonePayees(tx, milestone)
+		if err != nil {
+			return err
+		}
+		if len(payees) == 0 {
+			return fmt.Errorf("no assigned students to release the payment to")
+		}
+		return ledger.Release(tx, milestone.ProjectID, milestone.ID, payees, actorID)
+	}
+	return nil
+}
+
+// recordPortfolio writes approved work into the students' portfolios: approving a milestone creates their
+// verified entries and releasing it records what each was paid. rating is the partner's optional rating
+// out of 5. The milestone must be loaded with its Project and the call must share the status change's
+// transaction.
+func recordPortfolio(tx *gorm.DB, milestone Milestone, newStatus string, rating *float64) error {
+	switch newStatus {
+	case StatusApproved:
+		payees, err := milestonePayees(tx, milestone)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		work := portfolio.ApprovedWork{
+			ProjectID:   milestone.ProjectID,
+			MilestoneID: milestone.ID,
+			StudentIDs:  payees,
+			Scope:       milestone.Title + ": " + milestone.Scope,
+			Currency:    milestone.Currency,
+			OnTime:      true,
+			Rating:      rating,
+			ApprovedAt:  now,
+		}
+
+		// Judge timeliness by when the approved revision was handed in
+		deliveredAt := now
+		var latest MilestoneSubmission
+		if err := tx.Where("milestone_id = ?", milestone.ID).Order("revision DESC").First(&latest).Error; err == nil {
+			deliveredAt = latest.CreatedAt
+			work.Proof = latest.Files
+		}
+		if milestone.DueDate != nil {
+			work.OnTime = deliveredAt.Before(milestone.DueDate.AddDate(0, 0, 1))
+		}
+
+		share := 0.0
+		if milestone.Project.Budget.Value > 0 {
+			share = float64(milestone.Amount) / float64(milestone.Project.Budget.Value)
+		}
+		work.Complexity = portfolio.ComplexityForShare(share)
+
+		return portfolio.RecordMilestoneApproval(tx, work)
+	case StatusReleased:
+		return portfolio.RecordMilestonePayments(tx, milestone.ID)
+	}
+	return nil
+}
@@ -8,28 +8,79 @@ import (
 
 func RegisterRoutes(r fiber.Router, db *gorm.DB) {
 
-	milestones := r.Group("/milestones", user.JWTProtect([]string{"partner", "university-admin", "super-admin"}))
+	milestones := r.Group("/milestones", user.JWTProtect([]string{"partner", "student", "supervisor", "university-admin", "super-admin"}))
 
 	milestones.Get("/", func(c *fiber.Ctx) error {
 		return GetAll(c, db)
 	})
 
+	// Milestone templates (must come before /:id)
+	milestones.Get("/templates", func(c *fiber.Ctx) error {
+		return GetTemplates(c, db)
+	})
+
+	milestones.Post("/templates", func(c *fiber.Ctx) error {
+		return CreateTemplate(c, db)
+	})
+
+	milestones.Get("/templates/:id", func(c *fiber.Ctx) error {
+		return GetTemplate(c, db)
+	})
+
+	milestones.Put("/templates/:id", func(c *fiber.Ctx) error {
+		return UpdateTemplate(c, db)
+	})
+
+	milestones.Delete("/templates/:id", func(c *fiber.Ctx) error {
+		return DeleteTemplate(c, db)
+	})
+
+	milestones.Post("/templates/:id/apply", func(c *fiber.Ctx) error {
+		return ApplyTemplate(c, db)
+	})
+
 	milestones.Get("/:id", func(c *fiber.Ctx) error {
 		return GetByID(c, db)
 	})
 
-	milestones.Post("/", func(c *fiber.Ctx) error {
-		return Create(c, db)
+	milestones.Get("/:id/events", func(c *fiber.Ctx) error {
+		return GetEvents(c, db)
 	})
 
-	milestones.Put("/:id", func(c *fiber.Ctx) error {
-		return Update(c, db)
+	// Deliverable submissions and review comments
+	milestones.Post("/upload", func(c *fiber.Ctx) error {
+		return UploadFiles(c, db)
 	})
 
+	milestones.Get("/:id/submissions", func(c *fiber.Ctx) error {
+		return GetSubmissions(c, db)
+	})
+
+	milestones.Post("/:id/submissions", func(c *fiber.Ctx) error {
+		return CreateSubmission(c, db)
+	})
+
+	milestones.Get("/:id/submissions/:submissionId", func(c *fiber.Ctx) error {
+		return GetSubmission(c, db)
+	})
+
+	milestones.Post("/:id/submissions/:submissionId/comments", func(c *fiber.Ctx) error {
+		return AddComment(c, db)
+	})
+
+	milestones.Post("/", func(c *fiber.Ctx) error {
+		return Create(c, db)
+	})
+
+	// Must come before /:id
 	milestones.Put("/update-status", func(c *fiber.Ctx) error {
 		return UpdateStatus(c, db)
 	})
 
+	milestones.Put("/:id", func(c *fiber.Ctx) error {
+		return Update(c, db)
+	})
+
 	milestones.Delete("/:id", func(c *fiber.Ctx) error {
 		return Delete(c, db)
 	})
@@ -2,7 +2,9 @@ package milestone
 
 import (
 	"strconv"
+	"strings"
 
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
 	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -18,7 +20,6 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 		DueDate            string  `json:"dueDate"`
 		Amount             int     `json:"amount"`
 		Currency           string  `json:"currency,omitempty"`
-		Status             string  `json:"status,omitempty"`
 	}
 
 	var req CreateRequest
@@ -63,31 +64,46 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(403).JSON(fiber.Map{"msg": "not authorized to add milestones. Only project owner can add milestones."})
 	}
 
+	dueDate, err := ParseDueDate(req.DueDate)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+	}
+
 	// Build milestone from request
 	ms := Milestone{
 		ProjectID:          uint(ProjectID),
 		Title:              req.Title,
 		Scope:              req.Scope,
 		AcceptanceCriteria: req.AcceptanceCriteria,
-		DueDate:            req.DueDate,
+		DueDate:            dueDate,
 		Amount:             req.Amount,
 		Currency:           req.Currency,
-		Status:             req.Status,
+		Status:             StatusProposed, // Every milestone starts as a proposal to the assigned team
 	}
 
 	// Set defaults if not provided
 	if ms.AcceptanceCriteria == "" {
 		ms.AcceptanceCriteria = "To be defined"
 	}
-	if ms.Status == "" {
-		ms.Status = "PROPOSED"
-	}
 	if ms.Currency == "" {
-		// Use project currency if available
-		ms.Currency = "UGX" // Default fallback
+		ms.Currency = project.BudgetCurrency()
 	}
+	ms.Currency = strings.ToUpper(strings.TrimSpace(ms.Currency))
 
-	if err := db.Create(&ms).Error; err != nil {
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkAllocation(tx, ms.ProjectID, ms.Currency, ms.Amount, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(&ms).Error; err != nil {
+			return err
+		}
+		return tx.Create(&MilestoneEvent{
+			MilestoneID: ms.ID,
+			ActorID:     &UserID,
+			ActorRole:   actorPartner,
+			ToStatus:    ms.Status,
+		}).Error
+	}); err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to create milestone: " + err.Error()})
 	}
 
@@ -97,6 +113,9 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 	return c.Status(201).JSON(fiber.Map{"msg": "milestone created successfully", "data": ms})
 }
 
+// UpdateStatus moves a milestone along its lifecycle.
+// Assigned students accept, start and submit work, the supervisor or partner request changes, and the
+// partner approves and releases it. An optional {"note"} body is stored on the event.
 func UpdateStatus(c *fiber.Ctx, db *gorm.DB) error {
 
 	status := c.Query("status")
@@ -105,41 +124,125 @@ func UpdateStatus(c *fiber.Ctx, db *gorm.DB) error {
 	MilestoneID, _ := strconv.ParseUint(ms, 10, 64)
 
 	UserID := c.Locals("user_id").(uint)
-	userRole := c.Locals("role")
+	userRole, _ := c.Locals("role").(string)
 	var milestone Milestone
 	if err := db.Preload("Project").First(&milestone, MilestoneID).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone: " + err.Error()})
 	}
-	// Check authorization - allow project owner or super-admin
-	isAuthorized := UserID == milestone.Project.UserID || userRole == "super-admin"
-	if !isAuthorized {
-		return c.Status(403).JSON(fiber.Map{"msg": "not authorized to update milestone status"})
+
+	if !IsValidStatus(status) {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid status value"})
+	}
+	if status == StatusSubmitted {
+		return c.Status(400).JSON(fiber.Map{"msg": "submit work through /milestones/:id/submissions"})
+	}
+
+	// Check the move against the lifecycle and the caller's part in the project
+	actorRole, err := authorizeTransition(db, milestone, status, UserID, userRole)
+	if err == errNotAllowed {
+		return c.Status(403).JSON(fiber.Map{"msg": err.Error()})
+	}
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
 	}
 
-	if err := db.Model(&milestone).Update("status", status).Error; err != nil {
-		return c.Status(400).JSON(fiber.Map{"msg": "" + err.Error()})
+	type StatusRequest struct {
+		Note   string   `json:"note"`
+		Rating *float64 `json:"rating"` // Partner's rating out of 5 when approving
+	}
+	var req StatusRequest
+	c.BodyParser(&req) // Ignore error if body is empty
+	if req.Rating != nil && (*req.Rating < 1 || *req.Rating > 5) {
+		return c.Status(400).JSON(fiber.Map{"msg": "rating must be between 1 and 5"})
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := changeStatus(tx, &milestone, status, UserID, actorRole, req.Note); err != nil {
+			return err
+		}
+		if err := settleFunds(tx, milestone, status, UserID); err != nil {
+			return err
+		}
+		return recordPortfolio(tx, milestone, status, req.Rating)
+	}); err != nil {
+		return c.Status(transitionStatus(err)).JSON(fiber.Map{"msg": "failed to update milestone status: " + err.Error()})
 	}
 
 	return c.JSON(fiber.Map{"msg": "milestone status updated successfully", "data": milestone})
 }
 
+// GetEvents returns the status history of a milestone to the parties to its project
+func GetEvents(c *fiber.Ctx, db *gorm.DB) error {
+	UserID := c.Locals("user_id").(uint)
+	userRole, _ := c.Locals("role").(string)
+
+	milestone, err := loadMilestone(c, db)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "milestone not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone: " + err.Error()})
+	}
+
+	if !canViewMilestone(db, milestone, UserID, userRole) {
+		return c.Status(403).JSON(fiber.Map{"msg": "not authorized to view the history of this milestone"})
+	}
+
+	var events []MilestoneEvent
+	if err := db.Where("milestone_id = ?", milestone.ID).
+		Preload("Actor").
+		Order("created_at ASC").
+		Find(&events).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone history: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": events})
+}
+
 // GetAll retrieves all milestones with optional filters
 func GetAll(c *fiber.Ctx, db *gorm.DB) error {
 	var milestones []Milestone
 	query := db.Model(&Milestone{})
 
-	// Filter by projectId
-	if projectId := c.Query("projectId"); projectId != "" {
+	// Filter by project (?project= or ?projectId=)
+	var filterProjectID uint
+	projectId := c.Query("project")
+	if projectId == "" {
+		projectId = c.Query("projectId")
+	}
+	if projectId != "" {
 		projectIdUint, err := strconv.ParseUint(projectId, 10, 32)
 		if err == nil {
-			query = query.Where("project_id = ?", uint(projectIdUint))
+			filterProjectID = uint(projectIdUint)
+			query = query.Where("project_id = ?", filterProjectID)
 		}
 	}
 
+	// Students only see milestones of projects they are assigned to, supervisors those they supervise
+	UserID := c.Locals("user_id").(uint)
+	userRole, _ := c.Locals("role").(string)
+	if userRole == "student" {
+		query = query.Where("project_id IN (?)", project.AssignedProjectIDs(db, UserID))
+	} else if userRole == "supervisor" {
+		query = query.Where("project_id IN (?)", db.Table("projects").Select("id").Where("supervisor_id = ?", UserID))
+	}
+
 	if err := query.Preload("Project").Find(&milestones).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestones: " + err.Error()})
 	}
 
+	// A single project's listing also reports how its budget is allocated
+	if filterProjectID != 0 {
+		var proj project.Project
+		if err := db.First(&proj, filterProjectID).Error; err == nil {
+			budget, err := project.GetBudgetSummary(db, proj)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"msg": "failed to get budget summary: " + err.Error()})
+			}
+			return c.JSON(fiber.Map{"data": milestones, "budget": budget})
+		}
+	}
+
 	return c.JSON(fiber.Map{"data": milestones})
 }
 
@@ -186,13 +289,14 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 		DueDate            string `json:"dueDate,omitempty"`
 		Amount             *int   `json:"amount,omitempty"`
 		Currency           string `json:"currency,omitempty"`
-		Status             string `json:"status,omitempty"`
+		Status             string `json:"status,omitempty"` // Rejected; status moves through UpdateStatus
 	}
 
 	var req UpdateRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "invalid update data: " + err.Error()})
 	}
+	originalAmount, originalCurrency := milestone.Amount, milestone.Currency
 
 	// Update only provided fields
 	if req.Title != "" {
@@ -204,24 +308,50 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 	if req.AcceptanceCriteria != "" {
 		milestone.AcceptanceCriteria = req.AcceptanceCriteria
 	}
+	dueDateChanged := false
 	if req.DueDate != "" {
-		milestone.DueDate = req.DueDate
+		dueDate, err := ParseDueDate(req.DueDate)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+		}
+		dueDateChanged = milestone.DueDate == nil || !milestone.DueDate.Equal(*dueDate)
+		milestone.DueDate = dueDate
 	}
 	if req.Amount != nil {
 		milestone.Amount = *req.Amount
 	}
 	if req.Currency != "" {
-		milestone.Currency = req.Currency
+		milestone.Currency = strings.ToUpper(strings.TrimSpace(req.Currency))
+	}
+	if req.Status != "" && req.Status != milestone.Status {
+		return c.Status(400).JSON(fiber.Map{"msg": "milestone status can only be changed through update-status"})
 	}
-	if req.Status != "" {
-		milestone.Status = req.Status
+
+	// Money is held in escrow from acceptance, so the amount is fixed from then on
+	if (req.Amount != nil && *req.Amount != originalAmount) || (req.Currency != "" && !strings.EqualFold(req.Currency, originalCurrency)) {
+		if milestone.Status != StatusProposed {
+			return c.Status(400).JSON(fiber.Map{"msg": "the amount of an accepted milestone cannot be changed"})
+		}
 	}
 
 	// Don't allow changing project_id
 	// Save the original ProjectID before update
 	originalProjectID := milestone.ProjectID
 
-	if err := db.Model(&milestone).Updates(milestone).Error; err != nil {
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := checkAllocation(tx, originalProjectID, milestone.Currency, milestone.Amount, milestone.ID); err != nil {
+			return err
+		}
+		// The status only moves through changeStatus; writing back the loaded one could undo a concurrent move
+		if err := tx.Model(&milestone).Omit("status").Updates(milestone).Error; err != nil {
+			return err
+		}
+		// A new due date gets a fresh overdue check
+		if dueDateChanged {
+			return tx.Model(&milestone).Updates(map[string]interface{}{"overdue_at": nil, "escalated_at": nil}).Error
+		}
+		return nil
+	}); err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to update milestone: " + err.Error()})
 	}
 
@@ -259,7 +389,13 @@ func Delete(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(403).JSON(fiber.Map{"msg": "not authorized to delete this milestone"})
 	}
 
-	if err := db.Delete(&milestone).Error; err != nil {
+	// Anything held in escrow for the milestone goes back to the project
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := ledger.RefundMilestone(tx, milestone.ProjectID, milestone.ID, UserID, "milestone deleted"); err != nil {
+			return err
+		}
+		return tx.Delete(&milestone).Error
+	}); err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to delete milestone: " + err.Error()})
 	}
 
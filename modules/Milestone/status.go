@@ -0,0 +1,135 @@
+package milestone
+
+import (
+	"errors"
+	"fmt"
+
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"gorm.io/gorm"
+)
+
+// Milestone statuses
+const (
+	StatusProposed         = "PROPOSED"
+	StatusAccepted         = "ACCEPTED"
+	StatusInProgress       = "IN_PROGRESS"
+	StatusSubmitted        = "SUBMITTED"
+	StatusChangesRequested = "CHANGES_REQUESTED"
+	StatusApproved         = "APPROVED"
+	StatusReleased         = "RELEASED"
+)
+
+// Parties that may move a milestone. A user can act as several of them on the same project.
+const (
+	actorStudent    = "student"    // member of an ASSIGNED application's group
+	actorPartner    = "partner"    // owner of the project
+	actorSupervisor = "supervisor" // supervisor assigned to the project
+)
+
+// milestoneTransitions lists, for every status, the statuses a milestone may move to next and
+// which parties may make each move. Super-admins may make any listed move.
+var milestoneTransitions = map[string]map[string][]string{
+	StatusProposed:         {StatusAccepted: {actorStudent}},
+	StatusAccepted:         {StatusInProgress: {actorStudent}},
+	StatusInProgress:       {StatusSubmitted: {actorStudent}},
+	StatusSubmitted:        {StatusChangesRequested: {actorSupervisor, actorPartner}, StatusApproved: {actorPartner}},
+	StatusChangesRequested: {StatusInProgress: {actorStudent}, StatusSubmitted: {actorStudent}},
+	StatusApproved:         {StatusReleased: {actorPartner}},
+}
+
+// IsValidStatus reports whether status is a known milestone status
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusProposed, StatusAccepted, StatusInProgress, StatusSubmitted,
+		StatusChangesRequested, StatusApproved, StatusReleased:
+		return true
+	}
+	return false
+}
+
+// actorParties returns the parties a user acts as on a milestone's project.
+// The milestone must be loaded with its Project.
+func actorParties(db *gorm.DB, milestone Milestone, userID uint) map[string]bool {
+	parties := map[string]bool{}
+	if milestone.Project.UserID == userID {
+		parties[actorPartner] = true
+	}
+	if milestone.Project.SupervisorID != nil && *milestone.Project.SupervisorID == userID {
+		parties[actorSupervisor] = true
+	}
+	if project.IsAssignedStudent(db, milestone.ProjectID, userID) {
+		parties[actorStudent] = true
+	}
+	return parties
+}
+
+// errNotAllowed is returned when the caller is not one of the parties allowed to make a move
+var errNotAllowed = errors.New("you are not allowed to make this milestone transition")
+
+// authorizeTransition checks that the move is in the lifecycle and that the caller may make it.
+// It returns the party the caller acts as, which is recorded on the event.
+func authorizeTransition(db *gorm.DB, milestone Milestone, newStatus string, userID uint, role string) (string, error) {
+	allowed, ok := milestoneTransitions[milestone.Status][newStatus]
+	if !ok {
+		return "", fmt.Errorf("cannot move milestone from %s to %s", milestone.Status, newStatus)
+	}
+
+	if role == "super-admin" {
+		return role, nil
+	}
+
+	parties := actorParties(db, milestone, userID)
+	for _, party := range allowed {
+		if parties[party] {
+			return party, nil
+		}
+	}
+	return "", errNotAllowed
+}
+
+// errStatusConflict is returned when a milestone's status changed since it was loaded
+var errStatusConflict = errors.New("the milestone's status changed in the meantime; reload it and try again")
+
+// transitionStatus maps status change errors to HTTP statuses
+func transitionStatus(err error) int {
+	if errors.Is(err, errStatusConflict) {
+		return 409
+	}
+	return 400
+}
+
+// changeStatus moves the milestone to newStatus and records the transition.
+// actorID is 0 for system-driven transitions. The row is only updated if its status is still the one
+// loaded, so of two concurrent approvals or releases only one wins; the other gets errStatusConflict
+// and its transaction, with any funds or portfolio writes, is rolled back. Call it before those writes
+// so the loser waits on the row lock instead of doing the work twice.
+func changeStatus(tx *gorm.DB, milestone *Milestone, newStatus string, actorID uint, actorRole, note string) error {
+	from := milestone.Status
+	event := MilestoneEvent{
+		MilestoneID: milestone.ID,
+		ActorRole:   actorRole,
+		FromStatus:  from,
+		ToStatus:    newStatus,
+		Note:        note,
+	}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+
+	updates := map[string]interface{}{"status": newStatus}
+	if newStatus == StatusApproved || newStatus == StatusReleased {
+		// Approved work is no longer overdue
+		updates["overdue_at"] = nil
+		updates["escalated_at"] = nil
+	}
+	result := tx.Model(milestone).Where("status = ?", from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errStatusConflict
+	}
+	milestone.Status = newStatus
+
+	return tx.Create(&event).Error
+}
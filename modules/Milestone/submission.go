@@ -0,0 +1,293 @@
+package milestone
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// milestoneUploadDir is where UploadFiles stores submission files
+const milestoneUploadDir = "uploads/milestones"
+
+// canViewMilestone reports whether the caller may read a milestone's history, submissions and comments:
+// super-admins and the parties to its project.
+// The milestone must be loaded with its Project.
+func canViewMilestone(db *gorm.DB, milestone Milestone, userID uint, role string) bool {
+	if role == "super-admin" {
+		return true
+	}
+	return len(actorParties(db, milestone, userID)) > 0
+}
+
+// isUploadedFile reports whether path points at a file saved by UploadFiles
+func isUploadedFile(path string) bool {
+	clean := filepath.Clean(path)
+	if filepath.Dir(clean) != filepath.Clean(milestoneUploadDir) {
+		return false
+	}
+	info, err := os.Stat(clean)
+	return err == nil && !info.IsDir()
+}
+
+// loadMilestone loads the milestone named by the :id route parameter together with its project
+func loadMilestone(c *fiber.Ctx, db *gorm.DB) (Milestone, error) {
+	var milestone Milestone
+	err := db.Preload("Project").First(&milestone, c.Params("id")).Error
+	return milestone, err
+}
+
+// CreateSubmission hands in a new revision of a milestone's deliverables (assigned student action).
+// The milestone moves to SUBMITTED; revisions after the first count as rework.
+func CreateSubmission(c *fiber.Ctx, db *gorm.DB) error {
+	UserID := c.Locals("user_id").(uint)
+	userRole, _ := c.Locals("role").(string)
+
+	milestone, err := loadMilestone(c, db)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "milestone not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone: " + err.Error()})
+	}
+
+	type SubmissionRequest struct {
+		Notes string   `json:"notes"`
+		Files []string `json:"files"`
+	}
+	var req SubmissionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid submission: " + err.Error()})
+	}
+	if len(req.Files) == 0 && strings.TrimSpace(req.Notes) == "" {
+		return c.Status(400).JSON(fiber.Map{"msg": "a submission needs files or notes"})
+	}
+	for _, file := range req.Files {
+		if !isUploadedFile(file) {
+			return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("file %s was not uploaded through /milestones/upload", file)})
+		}
+	}
+
+	// Submitting is the IN_PROGRESS/CHANGES_REQUESTED -> SUBMITTED transition
+	actorRole, err := authorizeTransition(db, milestone, StatusSubmitted, UserID, userRole)
+	if err == errNotAllowed {
+		return c.Status(403).JSON(fiber.Map{"msg": "only students assigned to this project can submit work"})
+	}
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+	}
+
+	filesJSON, _ := json.Marshal(req.Files)
+	submission := MilestoneSubmission{
+		MilestoneID:   milestone.ID,
+		SubmittedByID: UserID,
+		GroupID:       project.AssignedGroupID(db, milestone.ProjectID, UserID),
+		Notes:         req.Notes,
+		Files:         datatypes.JSON(filesJSON),
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		var previous int64
+		if err := tx.Model(&MilestoneSubmission{}).Where("milestone_id = ?", milestone.ID).Count(&previous).Error; err != nil {
+			return err
+		}
+		submission.Revision = int(previous) + 1
+
+		if err := tx.Create(&submission).Error; err != nil {
+			return err
+		}
+		return changeStatus(tx, &milestone, StatusSubmitted, UserID, actorRole, fmt.Sprintf("revision %d submitted", submission.Revision))
+	}); err != nil {
+		return c.Status(transitionStatus(err)).JSON(fiber.Map{"msg": "failed to submit milestone: " + err.Error()})
+	}
+
+	db.Preload("SubmittedBy").First(&submission, submission.ID)
+
+	return c.Status(201).JSON(fiber.Map{"msg": "milestone submitted successfully", "data": submission})
+}
+
+// GetSubmissions lists every revision submitted for a milestone with its review comments
+func GetSubmissions(c *fiber.Ctx, db *gorm.DB) error {
+	UserID := c.Locals("user_id").(uint)
+	userRole, _ := c.Locals("role").(string)
+
+	milestone, err := loadMilestone(c, db)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "milestone not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone: " + err.Error()})
+	}
+
+	if !canViewMilestone(db, milestone, UserID, userRole) {
+		return c.Status(403).JSON(fiber.Map{"msg": "not authorized to view submissions for this milestone"})
+	}
+
+	var submissions []MilestoneSubmission
+	if err := db.Where("milestone_id = ?", milestone.ID).
+		Preload("SubmittedBy").
+		Preload("Comments", func(tx *gorm.DB) *gorm.DB { return tx.Order("created_at ASC") }).
+		Preload("Comments.Author").
+		Order("revision ASC").
+		Find(&submissions).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get submissions: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": submissions})
+}
+
+// GetSubmission returns a single revision with its review comments
+func GetSubmission(c *fiber.Ctx, db *gorm.DB) error {
+	UserID := c.Locals("user_id").(uint)
+	userRole, _ := c.Locals("role").(string)
+
+	milestone, err := loadMilestone(c, db)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "milestone not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone: " + err.Error()})
+	}
+
+	if !canViewMilestone(db, milestone, UserID, userRole) {
+		return c.Status(403).JSON(fiber.Map{"msg": "not authorized to view submissions for this milestone"})
+	}
+
+	var submission MilestoneSubmission
+	if err := db.Where("milestone_id = ?", milestone.ID).
+		Preload("SubmittedBy").
+		Preload("Comments", func(tx *gorm.DB) *gorm.DB { return tx.Order("created_at ASC") }).
+		Preload("Comments.Author").
+		First(&submission, c.Params("submissionId")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "submission not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get submission: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": submission})
+}
+
+// AddComment adds a review comment to a submission. The partner and supervisor review; assigned
+// students may reply within a thread.
+func AddComment(c *fiber.Ctx, db *gorm.DB) error {
+	UserID := c.Locals("user_id").(uint)
+	userRole, _ := c.Locals("role").(string)
+
+	milestone, err := loadMilestone(c, db)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "milestone not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone: " + err.Error()})
+	}
+
+	var submission MilestoneSubmission
+	if err := db.Where("milestone_id = ?", milestone.ID).First(&submission, c.Params("submissionId")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "submission not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get submission: " + err.Error()})
+	}
+
+	type CommentRequest struct {
+		Body     string `json:"body"`
+		ParentID *uint  `json:"parentId"`
+	}
+	var req CommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid comment: " + err.Error()})
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		return c.Status(400).JSON(fiber.Map{"msg": "comment body is required"})
+	}
+
+	// Reviewers start threads; students can only reply to them
+	authorRole := ""
+	if userRole == "super-admin" {
+		authorRole = userRole
+	} else {
+		parties := actorParties(db, milestone, UserID)
+		switch {
+		case parties[actorPartner]:
+			authorRole = actorPartner
+		case parties[actorSupervisor]:
+			authorRole = actorSupervisor
+		case parties[actorStudent] && req.ParentID != nil:
+			authorRole = actorStudent
+		case parties[actorStudent]:
+			return c.Status(403).JSON(fiber.Map{"msg": "students can only reply to review comments"})
+		default:
+			return c.Status(403).JSON(fiber.Map{"msg": "not authorized to comment on this submission"})
+		}
+	}
+
+	if req.ParentID != nil {
+		var parentCount int64
+		if err := db.Model(&SubmissionComment{}).
+			Where("id = ? AND submission_id = ?", *req.ParentID, submission.ID).
+			Count(&parentCount).Error; err != nil || parentCount == 0 {
+			return c.Status(400).JSON(fiber.Map{"msg": "parent comment not found on this submission"})
+		}
+	}
+
+	comment := SubmissionComment{
+		SubmissionID: submission.ID,
+		ParentID:     req.ParentID,
+		AuthorID:     UserID,
+		AuthorRole:   authorRole,
+		Body:         req.Body,
+	}
+	if err := db.Create(&comment).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to add comment: " + err.Error()})
+	}
+
+	db.Preload("Author").First(&comment, comment.ID)
+
+	return c.Status(201).JSON(fiber.Map{"msg": "comment added successfully", "data": comment})
+}
+
+// UploadFiles stores milestone deliverables and returns their paths for CreateSubmission
+func UploadFiles(c *fiber.Ctx, db *gorm.DB) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to parse form: " + err.Error()})
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "no files provided"})
+	}
+
+	// Create uploads directory if it doesn't exist
+	uploadDir := milestoneUploadDir
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		return c.Status(500).JSON(fiber.Map{"msg": "failed to create upload directory"})
+	}
+
+	var paths []string
+	for _, file := range files {
+		// Generate unique filename
+		filename := strconv.FormatInt(time.Now().UnixNano(), 10) + "_" + filepath.Base(file.Filename)
+		filePath := filepath.Join(uploadDir, filename)
+
+		if err := c.SaveFile(file, filePath); err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "failed to save file: " + err.Error()})
+		}
+
+		paths = append(paths, filePath)
+	}
+
+	return c.JSON(fiber.Map{
+		"msg":  "files uploaded successfully",
+		"data": fiber.Map{"paths": paths},
+	})
+}
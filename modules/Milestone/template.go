@@ -0,0 +1,391 @@
+package milestone
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// TemplateItemRequest is one milestone in a template create or update request
+type TemplateItemRequest struct {
+	Title              string  `json:"title"`
+	Scope              string  `json:"scope"`
+	AcceptanceCriteria string  `json:"acceptanceCriteria"`
+	DueOffsetDays      int     `json:"dueOffsetDays"`
+	BudgetPercent      float64 `json:"budgetPercent"`
+}
+
+// TemplateRequest is the body of template create and update requests
+type TemplateRequest struct {
+	OrganizationID uint                  `json:"organizationId"`
+	Name           string                `json:"name"`
+	Description    string                `json:"description"`
+	Items          []TemplateItemRequest `json:"items"`
+}
+
+// buildTemplateItems validates template items and orders them by due offset
+func buildTemplateItems(items []TemplateItemRequest) ([]MilestoneTemplateItem, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("a template needs at least one milestone")
+	}
+
+	var totalPercent float64
+	built := make([]MilestoneTemplateItem, 0, len(items))
+	for i, item := range items {
+		if strings.TrimSpace(item.Title) == "" {
+			return nil, fmt.Errorf("milestone %d needs a title", i+1)
+		}
+		if item.DueOffsetDays < 0 {
+			return nil, fmt.Errorf("milestone %d: dueOffsetDays cannot be negative", i+1)
+		}
+		if item.BudgetPercent < 0 || item.BudgetPercent > 100 {
+			return nil, fmt.Errorf("milestone %d: budgetPercent must be between 0 and 100", i+1)
+		}
+		totalPercent += item.BudgetPercent
+		built = append(built, MilestoneTemplateItem{
+			Title:              strings.TrimSpace(item.Title),
+			Scope:              item.Scope,
+			AcceptanceCriteria: item.AcceptanceCriteria,
+			DueOffsetDays:      item.DueOffsetDays,
+			BudgetPercent:      item.BudgetPercent,
+		})
+	}
+	if totalPercent > 100.0001 {
+		return nil, fmt.Errorf("budget percentages add up to %.2f%%, more than 100%%", totalPercent)
+	}
+
+	sort.SliceStable(built, func(i, j int) bool { return built[i].DueOffsetDays < built[j].DueOffsetDays })
+	for i := range built {
+		built[i].Position = i + 1
+	}
+	return built, nil
+}
+
+// callerOrganizationIDs returns the organizations the caller manages: the one they own, or the one
+// they have delegated access to
+func callerOrganizationIDs(db *gorm.DB, userID uint, role string) []uint {
+	var orgIDs []uint
+	if role == "delegated-admin" {
+		db.Table("delegated_accesses").
+			Where("delegated_user_id = ? AND is_active = ?", userID, true).
+			Pluck("organization_id", &orgIDs)
+	} else {
+		db.Table("organizations").Where("user_id = ? AND deleted_at IS NULL", userID).Pluck("id", &orgIDs)
+	}
+	return orgIDs
+}
+
+// canManageTemplates reports whether the caller may create or change templates of an organization
+func canManageTemplates(db *gorm.DB, orgID, userID uint, role string) bool {
+	if role == "super-admin" {
+		return true
+	}
+	for _, id := range callerOrganizationIDs(db, userID, role) {
+		if id == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+// templateOrganizationsForProject returns the organizations whose templates may be applied to a
+// project: the partner's own organization and the university hosting the project
+func templateOrganizationsForProject(db *gorm.DB, proj project.Project) []uint {
+	orgIDs := callerOrganizationIDs(db, proj.UserID, "partner")
+	var universityID uint
+	db.Table("departments").Where("id = ?", proj.DepartmentID).Select("organization_id").Scan(&universityID)
+	if universityID != 0 {
+		orgIDs = append(orgIDs, universityID)
+	}
+	return orgIDs
+}
+
+// loadTemplate loads a template with its items in order
+func loadTemplate(db *gorm.DB, id string) (MilestoneTemplate, error) {
+	var template MilestoneTemplate
+	err := db.Preload("Items", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("position ASC")
+	}).First(&template, id).Error
+	return template, err
+}
+
+// GetTemplates lists milestone templates. ?organization= picks one organization; otherwise the
+// caller's own organizations are listed (every template for super-admins).
+func GetTemplates(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	query := db.Model(&MilestoneTemplate{}).Preload("Items", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("position ASC")
+	})
+	if org := c.Query("organization"); org != "" {
+		orgID, err := strconv.ParseUint(org, 10, 32)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "invalid organization"})
+		}
+		query = query.Where("organization_id = ?", orgID)
+	} else if role != "super-admin" {
+		query = query.Where("organization_id IN ?", append(callerOrganizationIDs(db, userID, role), 0))
+	}
+
+	var templates []MilestoneTemplate
+	if err := query.Order("name ASC").Find(&templates).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone templates: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": templates})
+}
+
+// GetTemplate returns one milestone template
+func GetTemplate(c *fiber.Ctx, db *gorm.DB) error {
+	template, err := loadTemplate(db, c.Params("id"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "milestone template not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone template: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": template})
+}
+
+// CreateTemplate adds a milestone template to an organization the caller manages
+func CreateTemplate(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	var req TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid milestone template: " + err.Error()})
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return c.Status(400).JSON(fiber.Map{"msg": "name is required"})
+	}
+
+	// Default to the caller's own organization
+	if req.OrganizationID == 0 {
+		if orgIDs := callerOrganizationIDs(db, userID, role); len(orgIDs) > 0 {
+			req.OrganizationID = orgIDs[0]
+		}
+	}
+	if req.OrganizationID == 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "organizationId is required"})
+	}
+	if !canManageTemplates(db, req.OrganizationID, userID, role) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you can only add templates to your own organization"})
+	}
+
+	items, err := buildTemplateItems(req.Items)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+	}
+
+	template := MilestoneTemplate{
+		OrganizationID: req.OrganizationID,
+		Name:           strings.TrimSpace(req.Name),
+		Description:    req.Description,
+		CreatedByID:    userID,
+		Items:          items,
+	}
+	if err := db.Create(&template).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to create milestone template: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"msg": "milestone template created successfully", "data": template})
+}
+
+// UpdateTemplate renames a template and replaces its milestones
+func UpdateTemplate(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	template, err := loadTemplate(db, c.Params("id"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "milestone template not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone template: " + err.Error()})
+	}
+	if !canManageTemplates(db, template.OrganizationID, userID, role) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to update this template"})
+	}
+
+	var req TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid milestone template: " + err.Error()})
+	}
+	if strings.TrimSpace(req.Name) != "" {
+		template.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Description != "" {
+		template.Description = req.Description
+	}
+
+	var items []MilestoneTemplateItem
+	if req.Items != nil {
+		if items, err = buildTemplateItems(req.Items); err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+		}
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&template).Updates(map[string]interface{}{"name": template.Name, "description": template.Description}).Error; err != nil {
+			return err
+		}
+		if items == nil {
+			return nil
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&MilestoneTemplateItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].TemplateID = template.ID
+		}
+		return tx.Create(&items).Error
+	}); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to update milestone template: " + err.Error()})
+	}
+
+	template, _ = loadTemplate(db, c.Params("id"))
+	return c.JSON(fiber.Map{"msg": "milestone template updated successfully", "data": template})
+}
+
+// DeleteTemplate removes a template. Milestones created from it are kept.
+func DeleteTemplate(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	var template MilestoneTemplate
+	if err := db.First(&template, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "milestone template not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone template: " + err.Error()})
+	}
+	if !canManageTemplates(db, template.OrganizationID, userID, role) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to delete this template"})
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", template.ID).Delete(&MilestoneTemplateItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&template).Error
+	}); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to delete milestone template: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"msg": "milestone template deleted successfully"})
+}
+
+// ApplyTemplate creates a project's milestones from a template (project owner or super-admin).
+// Due dates are counted from startDate (default today) and amounts are shares of the project budget.
+func ApplyTemplate(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	type ApplyRequest struct {
+		ProjectID uint   `json:"projectId"`
+		StartDate string `json:"startDate"`
+	}
+	var req ApplyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid request: " + err.Error()})
+	}
+
+	template, err := loadTemplate(db, c.Params("id"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "milestone template not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get milestone template: " + err.Error()})
+	}
+
+	var proj project.Project
+	if err := db.First(&proj, req.ProjectID).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"msg": "project not found"})
+	}
+	if role != "super-admin" && proj.UserID != userID {
+		return c.Status(403).JSON(fiber.Map{"msg": "only the project owner can add milestones"})
+	}
+
+	allowed := false
+	for _, orgID := range templateOrganizationsForProject(db, proj) {
+		if orgID == template.OrganizationID {
+			allowed = true
+			break
+		}
+	}
+	if !allowed && role != "super-admin" {
+		return c.Status(403).JSON(fiber.Map{"msg": "this template belongs to another organization"})
+	}
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if req.StartDate != "" {
+		if start, err = time.Parse("2006-01-02", req.StartDate); err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "startDate must be a date (YYYY-MM-DD)"})
+		}
+	}
+
+	// Amounts are rounded down; a template covering the whole budget gives the remainder to its last milestone
+	budget := int(proj.Budget.Value)
+	var totalPercent float64
+	milestones := make([]Milestone, len(template.Items))
+	allocated := 0
+	for i, item := range template.Items {
+		amount := int(math.Floor(float64(budget) * item.BudgetPercent / 100))
+		dueDate := start.AddDate(0, 0, item.DueOffsetDays)
+		allocated += amount
+		totalPercent += item.BudgetPercent
+		milestones[i] = Milestone{
+			ProjectID:          proj.ID,
+			Title:              item.Title,
+			Scope:              item.Scope,
+			AcceptanceCriteria: item.AcceptanceCriteria,
+			DueDate:            &dueDate,
+			Amount:             amount,
+			Currency:           proj.BudgetCurrency(),
+			Status:             StatusProposed,
+		}
+		if milestones[i].AcceptanceCriteria == "" {
+			milestones[i].AcceptanceCriteria = "To be defined"
+		}
+	}
+	if len(milestones) > 0 && math.Abs(totalPercent-100) < 0.0001 {
+		milestones[len(milestones)-1].Amount += budget - allocated
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range milestones {
+			ms := &milestones[i]
+			if err := checkAllocation(tx, ms.ProjectID, ms.Currency, ms.Amount, 0); err != nil {
+				return fmt.Errorf("%s: %w", ms.Title, err)
+			}
+			if err := tx.Create(ms).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&MilestoneEvent{
+				MilestoneID: ms.ID,
+				ActorID:     &userID,
+				ActorRole:   actorPartner,
+				ToStatus:    ms.Status,
+				Note:        "created from template " + template.Name,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to apply milestone template: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"msg": fmt.Sprintf("%d milestones created from template", len(milestones)), "data": milestones})
+}
@@ -21,6 +21,7 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 	if err := db.Create(&notification).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to send notification"})
 	}
+	notificationCreated(notification)
 	return c.Status(201).JSON(fiber.Map{"data": notification})
 
 }
@@ -87,3 +88,53 @@ func MarkAllAsRead(c *fiber.Ctx, db *gorm.DB) error {
 
 	return c.JSON(fiber.Map{"msg": "all notifications marked as read"})
 }
+
+// Notify creates the same notification for each of the given users and runs the creation hooks.
+// Inside a transaction, use Stage and Dispatch the result after commit so nothing is pushed for a rollback.
+func Notify(db *gorm.DB, userIDs []uint, notifType, title, message, link string) error {
+	created, err := Stage(db, userIDs, notifType, title, message, link)
+	Dispatch(created)
+	return err
+}
+
+// Stage creates the same notification for each of the given users without running the creation hooks
+func Stage(db *gorm.DB, userIDs []uint, notifType, title, message, link string) ([]Notification, error) {
+	var created []Notification
+	for _, userID := range userIDs {
+		notification := Notification{
+			Type:    notifType,
+			Title:   title,
+			Message: message,
+			Link:    link,
+			UserID:  userID,
+		}
+		if err := db.Create(&notification).Error; err != nil {
+			return created, err
+		}
+		created = append(created, notification)
+	}
+	return created, nil
+}
+
+// Dispatch runs the creation hooks for staged notifications once they are committed
+func Dispatch(notifications []Notification) {
+	for _, notification := range notifications {
+		notificationCreated(notification)
+	}
+}
+
+// createdHooks run after a notification is created
+var createdHooks []func(Notification)
+
+// OnCreated registers work to do when a notification is created, such as pushing it to the user's
+// open connections (call this from main.go)
+func OnCreated(hook func(Notification)) {
+	createdHooks = append(createdHooks, hook)
+}
+
+// notificationCreated runs the registered creation hooks
+func notificationCreated(notification Notification) {
+	for _, hook := range createdHooks {
+		hook(notification)
+	}
+}
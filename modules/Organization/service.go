@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	exchangerate "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/ExchangeRate"
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
 	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -399,19 +401,32 @@ type recentProjectResponse struct {
 }
 
 type dashboardStatsResponse struct {
-	TotalStudents   int64                     `json:"totalStudents"`
-	ActiveProjects  int64                     `json:"activeProjects"`
-	PendingReviews  int64                     `json:"pendingReviews"`
-	DepartmentStats []departmentStatsResponse `json:"departmentStats"`
-	RecentProjects  []recentProjectResponse   `json:"recentProjects"`
-	StudentTrend    []studentTrendPoint       `json:"studentTrend"`
+	TotalStudents       int64                     `json:"totalStudents"`
+	ActiveProjects      int64                     `json:"activeProjects"`
+	PendingReviews      int64                     `json:"pendingReviews"`
+	OverdueMilestones   int64                     `json:"overdueMilestones"`
+	EscalatedMilestones int64                     `json:"escalatedMilestones"`
+	DepartmentStats     []departmentStatsResponse `json:"departmentStats"`
+	RecentProjects      []recentProjectResponse   `json:"recentProjects"`
+	StudentTrend        []studentTrendPoint       `json:"studentTrend"`
 }
 
 type partnerDashboardStatsResponse struct {
 	TotalProjects     int64   `json:"totalProjects"`
 	ActiveProjects    int64   `json:"activeProjects"`
 	CompletedProjects int64   `json:"completedProjects"`
+	OverdueMilestones int64   `json:"overdueMilestones"`
 	TotalBudget       float64 `json:"totalBudget"`
+	// Money actually moved through the ledger, converted to Currency and broken down per original currency
+	TotalFunded     float64                   `json:"totalFunded"`
+	Available       float64                   `json:"available"`
+	InEscrow        float64                   `json:"inEscrow"`
+	Released        float64                   `json:"released"`
+	Refunded        float64                   `json:"refunded"`
+	FundsByCurrency map[string]ledger.Summary `json:"fundsByCurrency"`
+	// Amounts in UnconvertedCurrencies had no exchange rate to Currency and are left out of the totals
+	Currency              string   `json:"currency"`
+	UnconvertedCurrencies []string `json:"unconvertedCurrencies,omitempty"`
 }
 
 type studentTrendPoint struct {
@@ -463,7 +478,7 @@ func buildDashboardStats(db *gorm.DB, orgID uint) (dashboardStatsResponse, error
 		return stats, err
 	}
 
-	reviewStatuses := []string{"SUPERVISOR_REVIEW", "PARTNER_REVIEW"}
+	reviewStatuses := []string{"SUBMITTED"}
 	reviewQuery := db.Table("milestones").
 		Joins("JOIN projects ON milestones.project_id = projects.id").
 		Joins("JOIN departments ON projects.department_id = departments.id").
@@ -473,6 +488,20 @@ func buildDashboardStats(db *gorm.DB, orgID uint) (dashboardStatsResponse, error
 		return stats, err
 	}
 
+	// Milestones flagged by the overdue job, and those already escalated to the university
+	overdueQuery := db.Table("milestones").
+		Joins("JOIN projects ON milestones.project_id = projects.id").
+		Joins("JOIN departments ON projects.department_id = departments.id").
+		Where("departments.organization_id = ?", orgID).
+		Where("milestones.overdue_at IS NOT NULL AND milestones.deleted_at IS NULL").
+		Session(&gorm.Session{})
+	if err := overdueQuery.Count(&stats.OverdueMilestones).Error; err != nil {
+		return stats, err
+	}
+	if err := overdueQuery.Where("milestones.escalated_at IS NOT NULL").Count(&stats.EscalatedMilestones).Error; err != nil {
+		return stats, err
+	}
+
 	var departments []struct {
 		ID   uint
 		Name string
@@ -730,7 +759,7 @@ func GetPartnerDashboardStats(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"msg": "access denied"})
 	}
 
-	stats, err := buildPartnerDashboardStats(db, userID)
+	stats, err := buildPartnerDashboardStats(db, userID, exchangerate.ReportingCurrency(c.Query("currency")))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"msg": "failed to load dashboard stats: " + err.Error()})
 	}
@@ -738,8 +767,14 @@ func GetPartnerDashboardStats(c *fiber.Ctx, db *gorm.DB) error {
 	return c.JSON(fiber.Map{"data": stats})
 }
 
-func buildPartnerDashboardStats(db *gorm.DB, partnerID uint) (partnerDashboardStatsResponse, error) {
-	stats := partnerDashboardStatsResponse{}
+func buildPartnerDashboardStats(db *gorm.DB, partnerID uint, currency string) (partnerDashboardStatsResponse, error) {
+	stats := partnerDashboardStatsResponse{Currency: currency}
+
+	conv, err := exchangerate.NewConverter(db)
+	if err != nil {
+		return stats, err
+	}
+	now := time.Now()
 
 	// Count total projects
 	if err := db.Table("projects").Where("user_id = ?", partnerID).Count(&stats.TotalProjects).Error; err != nil {
@@ -760,20 +795,47 @@ func buildPartnerDashboardStats(db *gorm.DB, partnerID uint) (partnerDashboardSt
 		return stats, err
 	}
 
-	// Calculate total budget
+	// Count milestones flagged by the overdue job
+	if err := db.Table("milestones").
+		Joins("JOIN projects ON milestones.project_id = projects.id").
+		Where("projects.user_id = ? AND milestones.overdue_at IS NOT NULL AND milestones.deleted_at IS NULL", partnerID).
+		Count(&stats.OverdueMilestones).Error; err != nil {
+		return stats, err
+	}
+
+	// Calculate total budget in the reporting currency
 	var budgetRows []struct {
-		BudgetValue uint `gorm:"column:budget_value"`
+		BudgetValue    uint   `gorm:"column:budget_value"`
+		BudgetCurrency string `gorm:"column:budget_currency"`
 	}
 	if err := db.Table("projects").
-		Select("budget_value").
-		Where("user_id = ?", partnerID).
+		Select("budget_value, budget_currency").
+		Where("user_id = ? AND deleted_at IS NULL", partnerID).
 		Scan(&budgetRows).Error; err != nil {
 		return stats, err
 	}
 
 	for _, row := range budgetRows {
-		stats.TotalBudget += float64(row.BudgetValue)
+		stats.TotalBudget += conv.Sum(float64(row.BudgetValue), row.BudgetCurrency, currency, now)
+	}
+
+	var projectIDs []uint
+	if err := db.Table("projects").Where("user_id = ?", partnerID).Pluck("id", &projectIDs).Error; err != nil {
+		return stats, err
+	}
+	funds, err := ledger.ProjectSummaries(db, projectIDs)
+	if err != nil {
+		return stats, err
+	}
+	stats.FundsByCurrency = funds
+	for code, summary := range funds {
+		stats.TotalFunded += conv.Sum(float64(summary.Funded), code, currency, now)
+		stats.Available += conv.Sum(float64(summary.Available), code, currency, now)
+		stats.InEscrow += conv.Sum(float64(summary.InEscrow), code, currency, now)
+		stats.Released += conv.Sum(float64(summary.Released), code, currency, now)
+		stats.Refunded += conv.Sum(float64(summary.Refunded), code, currency, now)
 	}
+	stats.UnconvertedCurrencies = conv.Unconverted()
 
 	return stats, nil
 }
@@ -0,0 +1,146 @@
+package payment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FakeProviderName is the name of the in-process provider used for local development
+const FakeProviderName = "fake"
+
+// FakeSignatureHeader carries the signature of fake webhook deliveries
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider settles payments in process without contacting any gateway. Outcomes depend only on
+// the request: accounts containing "fail" are declined, accounts containing "pending" stay pending until
+// a webhook settles them, and everything else succeeds at once. References are derived from the
+// idempotency key so retries return the same reference.
+type FakeProvider struct {
+	secret   string
+	mu       sync.Mutex
+	payments map[string]Result
+}
+
+// NewFakeProvider creates a fake provider that signs webhooks with secret
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{secret: secret, payments: map[string]Result{}}
+}
+
+// RegisterFakeProvider registers the fake provider when PAYMENT_FAKE=1 and a webhook secret is
+// configured. It moves no real money, so it is never registered by default. It reads the environment, so
+// call it after .env is loaded.
+func RegisterFakeProvider() {
+	if os.Getenv("PAYMENT_FAKE") != "1" {
+		return
+	}
+	secret := os.Getenv("PAYMENT_FAKE_WEBHOOK_SECRET")
+	if secret == "" {
+		log.Println("PAYMENT_FAKE is set but PAYMENT_FAKE_WEBHOOK_SECRET is empty; the fake payment provider is disabled")
+		return
+	}
+	RegisterProvider(NewFakeProvider(secret))
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+// settle records the deterministic outcome of a charge or payout
+func (p *FakeProvider) settle(kind, idempotencyKey, account string, amount int64) (Result, error) {
+	if idempotencyKey == "" {
+		return Result{}, fmt.Errorf("%w: an idempotency key is required", ErrRejected)
+	}
+	if amount <= 0 {
+		return Result{}, fmt.Errorf("%w: amount must be positive", ErrRejected)
+	}
+
+	sum := sha256.Sum256([]byte(kind + ":" + idempotencyKey))
+	reference := "fake_" + kind + "_" + hex.EncodeToString(sum[:8])
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if existing, ok := p.payments[reference]; ok {
+		return existing, nil
+	}
+
+	result := Result{Reference: reference, Status: StatusSucceeded}
+	account = strings.ToLower(account)
+	if strings.Contains(account, "fail") {
+		result.Status = StatusFailed
+		result.FailureReason = "declined by fake provider"
+	} else if strings.Contains(account, "pending") {
+		result.Status = StatusPending
+	}
+	p.payments[reference] = result
+	return result, nil
+}
+
+func (p *FakeProvider) InitiateCharge(req ChargeRequest) (Result, error) {
+	return p.settle("charge", req.IdempotencyKey, req.Account, req.Amount)
+}
+
+func (p *FakeProvider) Payout(req PayoutRequest) (Result, error) {
+	return p.settle("payout", req.IdempotencyKey, req.Account, req.Amount)
+}
+
+func (p *FakeProvider) CheckStatus(reference string) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, ok := p.payments[reference]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown payment reference %s", reference)
+	}
+	return result, nil
+}
+
+// fakeWebhookPayload is the body of a fake webhook delivery
+type fakeWebhookPayload struct {
+	EventID       string `json:"eventId"`
+	Reference     string `json:"reference"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+func (p *FakeProvider) HandleWebhook(body []byte, header func(key string, defaultValue ...string) string) (WebhookEvent, error) {
+	if !VerifyHMAC(p.secret, body, header(FakeSignatureHeader)) {
+		return WebhookEvent{}, ErrInvalidSignature
+	}
+
+	var payload fakeWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return WebhookEvent{}, fmt.Errorf("invalid webhook body: %w", err)
+	}
+	if payload.EventID == "" || payload.Reference == "" {
+		return WebhookEvent{}, fmt.Errorf("eventId and reference are required")
+	}
+	if payload.Status != StatusSucceeded && payload.Status != StatusFailed && payload.Status != StatusPending {
+		return WebhookEvent{}, fmt.Errorf("invalid status %s", payload.Status)
+	}
+
+	// Keep CheckStatus consistent with what the webhook reported
+	p.mu.Lock()
+	if result, ok := p.payments[payload.Reference]; ok {
+		result.Status = payload.Status
+		result.FailureReason = payload.FailureReason
+		p.payments[payload.Reference] = result
+	}
+	p.mu.Unlock()
+
+	return WebhookEvent{
+		EventID:       payload.EventID,
+		Reference:     payload.Reference,
+		Status:        payload.Status,
+		FailureReason: payload.FailureReason,
+	}, nil
+}
+
+// Sign returns the signature header value for a webhook body, for simulating deliveries locally
+func (p *FakeProvider) Sign(body []byte) string {
+	return SignHMAC(p.secret, body)
+}
@@ -0,0 +1,39 @@
+package payment
+
+import (
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Payment kinds
+const (
+	KindCharge = "CHARGE" // Partner funding a project
+	KindPayout = "PAYOUT" // Student withdrawing released earnings
+)
+
+// Payment tracks one charge or payout through a provider
+type Payment struct {
+	gorm.Model
+	Provider            string  `json:"provider" gorm:"uniqueIndex:idx_payment_reference;not null"`
+	Reference           *string `json:"reference" gorm:"uniqueIndex:idx_payment_reference"` // Set once the provider accepts the request
+	IdempotencyKey      string  `json:"idempotencyKey" gorm:"uniqueIndex;not null"`
+	Kind                string  `json:"kind" gorm:"index;not null"`
+	Status              string  `json:"status" gorm:"index;not null"` // PENDING, SUCCEEDED or FAILED
+	UserID              uint    `json:"userId" gorm:"index;not null"` // Partner for charges, student for payouts
+	ProjectID           *uint   `json:"projectId" gorm:"index"`       // Charges only
+	Account             string  `json:"account"`
+	Currency            string  `json:"currency" gorm:"not null"`
+	Amount              int64   `json:"amount"`
+	LedgerTransactionID *uint   `json:"ledgerTransactionId"`
+	FailureReason       string  `json:"failureReason"`
+}
+
+// PaymentWebhookEvent records every webhook delivery that has been processed, so retries are ignored
+type PaymentWebhookEvent struct {
+	gorm.Model
+	Provider  string         `json:"provider" gorm:"uniqueIndex:idx_webhook_event;not null"`
+	EventID   string         `json:"eventId" gorm:"uniqueIndex:idx_webhook_event;not null"`
+	Reference string         `json:"reference" gorm:"index"`
+	Status    string         `json:"status"`
+	Payload   datatypes.JSON `json:"payload"`
+}
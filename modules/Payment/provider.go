@@ -0,0 +1,120 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Payment statuses reported by providers
+const (
+	StatusPending   = "PENDING"
+	StatusSucceeded = "SUCCEEDED"
+	StatusFailed    = "FAILED"
+)
+
+// ErrInvalidSignature is returned when a webhook does not carry a valid signature
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// ErrRejected is wrapped by providers when they refuse a request outright, so it can be marked failed.
+// Any other error, such as a timeout, leaves the outcome unknown.
+var ErrRejected = errors.New("rejected by payment provider")
+
+// ChargeRequest asks a provider to collect money from a payer
+type ChargeRequest struct {
+	IdempotencyKey string
+	Amount         int64
+	Currency       string
+	Account        string // Payer's account, e.g. a mobile money number
+	Description    string
+}
+
+// PayoutRequest asks a provider to send money to a payee
+type PayoutRequest struct {
+	IdempotencyKey string
+	Amount         int64
+	Currency       string
+	Account        string // Payee's account, e.g. a mobile money number
+	Description    string
+}
+
+// Result is a provider's view of a charge or payout
+type Result struct {
+	Reference     string // Provider's identifier for the charge or payout
+	Status        string
+	FailureReason string
+}
+
+// WebhookEvent is a verified status update sent by a provider
+type WebhookEvent struct {
+	EventID       string // Unique per event; used to drop retried deliveries
+	Reference     string
+	Status        string
+	FailureReason string
+}
+
+// PaymentProvider is implemented by every payment gateway. Providers must treat the idempotency key
+// as a request identifier so that a retried call does not charge or pay twice. A declined payment is
+// reported as a Result with StatusFailed or an error wrapping ErrRejected.
+type PaymentProvider interface {
+	Name() string
+	InitiateCharge(req ChargeRequest) (Result, error)
+	Payout(req PayoutRequest) (Result, error)
+	CheckStatus(reference string) (Result, error)
+	// HandleWebhook verifies the signature of a webhook delivery and parses it. It returns
+	// ErrInvalidSignature when the delivery cannot be trusted.
+	HandleWebhook(body []byte, header func(key string, defaultValue ...string) string) (WebhookEvent, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]PaymentProvider{}
+)
+
+// RegisterProvider makes a provider available by name
+func RegisterProvider(provider PaymentProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[provider.Name()] = provider
+}
+
+// GetProvider returns a registered provider
+func GetProvider(name string) (PaymentProvider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	provider, ok := providers[name]
+	return provider, ok
+}
+
+// DefaultProvider returns the provider named by PAYMENT_PROVIDER. There is no fallback: without a
+// configured provider, payments are refused.
+func DefaultProvider() (PaymentProvider, bool) {
+	name := strings.TrimSpace(os.Getenv("PAYMENT_PROVIDER"))
+	if name == "" {
+		return nil, false
+	}
+	return GetProvider(name)
+}
+
+// VerifyHMAC checks a hex encoded HMAC-SHA256 signature of body. Providers that sign webhooks this way
+// can share it.
+func VerifyHMAC(secret string, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(strings.TrimSpace(signature))
+	if err != nil || secret == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// SignHMAC returns the hex encoded HMAC-SHA256 signature of body
+func SignHMAC(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
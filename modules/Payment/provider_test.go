@@ -0,0 +1,161 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+)
+
+func headers(values map[string]string) func(key string, defaultValue ...string) string {
+	return func(key string, defaultValue ...string) string {
+		return values[key]
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	body := []byte(`{"eventId":"evt_1"}`)
+	valid := SignHMAC("secret", body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{"valid signature", "secret", body, valid, true},
+		{"surrounding whitespace", "secret", body, " " + valid + "\n", true},
+		{"wrong secret", "other", body, valid, false},
+		{"tampered body", "secret", []byte(`{"eventId":"evt_2"}`), valid, false},
+		{"empty signature", "secret", body, "", false},
+		{"not hex", "secret", body, "zz" + valid[2:], false},
+		{"truncated", "secret", body, valid[:len(valid)-2], false},
+		{"empty secret", "", body, SignHMAC("", body), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyHMAC(tt.secret, tt.body, tt.signature); got != tt.want {
+				t.Errorf("VerifyHMAC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeProviderWebhookSignature(t *testing.T) {
+	provider := NewFakeProvider("secret")
+	body := []byte(`{"eventId":"evt_1","reference":"fake_charge_1","status":"SUCCEEDED"}`)
+
+	tests := []struct {
+		name      string
+		signature string
+		wantErr   error
+	}{
+		{"signed by the provider", provider.Sign(body), nil},
+		{"missing signature", "", ErrInvalidSignature},
+		{"signed with another secret", NewFakeProvider("other").Sign(body), ErrInvalidSignature},
+		{"signature of another body", provider.Sign([]byte(`{}`)), ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := provider.HandleWebhook(body, headers(map[string]string{FakeSignatureHeader: tt.signature}))
+			if err != tt.wantErr {
+				t.Fatalf("HandleWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (event.EventID != "evt_1" || event.Status != StatusSucceeded) {
+				t.Errorf("HandleWebhook() = %+v", event)
+			}
+		})
+	}
+}
+
+func TestDefaultProviderRequiresConfiguration(t *testing.T) {
+	RegisterProvider(NewFakeProvider("secret"))
+
+	t.Setenv("PAYMENT_PROVIDER", "")
+	if _, ok := DefaultProvider(); ok {
+		t.Error("DefaultProvider() returned a provider with PAYMENT_PROVIDER unset")
+	}
+
+	t.Setenv("PAYMENT_PROVIDER", "unknown")
+	if _, ok := DefaultProvider(); ok {
+		t.Error("DefaultProvider() returned a provider for an unregistered name")
+	}
+
+	t.Setenv("PAYMENT_PROVIDER", FakeProviderName)
+	if provider, ok := DefaultProvider(); !ok || provider.Name() != FakeProviderName {
+		t.Error("DefaultProvider() did not return the configured provider")
+	}
+}
+
+func TestRegisterFakeProviderReadsEnvironment(t *testing.T) {
+	tests := []struct {
+		name, enabled, secret string
+		want                  bool
+	}{
+		{"not enabled", "", "secret", false},
+		{"enabled without a secret", "1", "", false},
+		{"enabled with a secret", "1", "secret", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providersMu.Lock()
+			delete(providers, FakeProviderName)
+			providersMu.Unlock()
+
+			t.Setenv("PAYMENT_FAKE", tt.enabled)
+			t.Setenv("PAYMENT_FAKE_WEBHOOK_SECRET", tt.secret)
+			RegisterFakeProvider()
+			if _, ok := GetProvider(FakeProviderName); ok != tt.want {
+				t.Errorf("fake provider registered = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+// failingProvider answers every charge and payout with err
+type failingProvider struct {
+	FakeProvider
+	err error
+}
+
+func (p *failingProvider) InitiateCharge(req ChargeRequest) (Result, error) {
+	return Result{}, p.err
+}
+
+func (p *failingProvider) Payout(req PayoutRequest) (Result, error) {
+	return Result{}, p.err
+}
+
+func TestSubmitKeepsPaymentPendingWhenOutcomeIsUnknown(t *testing.T) {
+	for _, kind := range []string{KindCharge, KindPayout} {
+		t.Run(kind, func(t *testing.T) {
+			payment := Payment{Kind: kind, Status: StatusPending, IdempotencyKey: "key", Amount: 100}
+			provider := &failingProvider{err: errors.New("connection timed out")}
+			// A nil database would panic if submit tried to settle the payment
+			settled, err := submit(nil, provider, &payment)
+			if err != nil {
+				t.Fatalf("submit() error = %v", err)
+			}
+			if settled.Status != StatusPending {
+				t.Errorf("status = %s, want %s", settled.Status, StatusPending)
+			}
+		})
+	}
+}
+
+func TestFakeProviderRejectsInvalidRequests(t *testing.T) {
+	provider := NewFakeProvider("secret")
+	tests := []struct {
+		name string
+		req  PayoutRequest
+	}{
+		{"missing idempotency key", PayoutRequest{Amount: 100, Account: "256700000000"}},
+		{"non-positive amount", PayoutRequest{IdempotencyKey: "key", Account: "256700000000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := provider.Payout(tt.req); !errors.Is(err, ErrRejected) {
+				t.Errorf("Payout() error = %v, want ErrRejected", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package payment
+
+import (
+	"log"
+
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RegisterRoutes registers payment routes
+func RegisterRoutes(r fiber.Router, db *gorm.DB) {
+	if _, ok := DefaultProvider(); !ok {
+		log.Println("No payment provider is configured; charges and payouts will be refused until PAYMENT_PROVIDER is set")
+	}
+
+	// Providers call webhooks without a user session; deliveries are authenticated by their signature
+	r.Post("/payment-webhooks/:provider", func(c *fiber.Ctx) error {
+		return HandleWebhook(c, db)
+	})
+
+	payments := r.Group("/payments", user.JWTProtect([]string{"partner", "student", "super-admin"}))
+
+	payments.Get("/", func(c *fiber.Ctx) error {
+		return GetAll(c, db)
+	})
+
+	payments.Post("/charges", func(c *fiber.Ctx) error {
+		return CreateCharge(c, db)
+	})
+
+	payments.Post("/payouts", func(c *fiber.Ctx) error {
+		return CreatePayout(c, db)
+	})
+
+	payments.Get("/:id", func(c *fiber.Ctx) error {
+		return GetByID(c, db)
+	})
+}
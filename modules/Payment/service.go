@@ -0,0 +1,341 @@
+package payment
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
+	notification "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Notification"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// idempotencyKey scopes the client's Idempotency-Key header to the caller. Requests without the header
+// get a random key and are never treated as retries.
+func idempotencyKey(c *fiber.Ctx, kind string, userID uint) string {
+	if key := strings.TrimSpace(c.Get("Idempotency-Key")); key != "" {
+		return kind + ":" + strconv.FormatUint(uint64(userID), 10) + ":" + key
+	}
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return kind + ":" + hex.EncodeToString(buf)
+}
+
+// existingPayment returns the payment already created for an idempotency key, if any
+func existingPayment(db *gorm.DB, key string) (*Payment, bool) {
+	var payment Payment
+	if err := db.Where("idempotency_key = ?", key).First(&payment).Error; err != nil {
+		return nil, false
+	}
+	return &payment, true
+}
+
+// submit hands a stored payment to its provider and applies the provider's answer. Only a definite
+// rejection fails it: when the outcome is unknown, such as after a timeout, the provider may already
+// have moved the money, so the payment stays pending until CheckStatus or a webhook settles it.
+func submit(db *gorm.DB, provider PaymentProvider, payment *Payment) (*Payment, error) {
+	result, err := send(provider, *payment)
+	if err != nil {
+		if !errors.Is(err, ErrRejected) {
+			log.Printf("Payment %d was sent to %s with an unknown outcome: %v", payment.ID, provider.Name(), err)
+			return payment, nil
+		}
+		result = Result{Status: StatusFailed, FailureReason: err.Error()}
+	}
+
+	var settled *Payment
+	var staged []notification.Notification
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if result.Reference != "" {
+			if err := tx.Model(payment).Update("reference", result.Reference).Error; err != nil {
+				return err
+			}
+		}
+		var err error
+		settled, staged, err = applyResult(tx, payment.ID, result.Status, result.FailureReason)
+		return err
+	})
+	if err == nil {
+		notification.Dispatch(staged)
+	}
+	return settled, err
+}
+
+// send asks the provider to make a stored charge or payout. It reuses the payment's idempotency key, so
+// sending the same payment again never charges or pays twice.
+func send(provider PaymentProvider, payment Payment) (Result, error) {
+	if payment.Kind == KindCharge {
+		return provider.InitiateCharge(ChargeRequest{
+			IdempotencyKey: payment.IdempotencyKey,
+			Amount:         payment.Amount,
+			Currency:       payment.Currency,
+			Account:        payment.Account,
+			Description:    "Project funding",
+		})
+	}
+	return provider.Payout(PayoutRequest{
+		IdempotencyKey: payment.IdempotencyKey,
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		Account:        payment.Account,
+		Description:    "Milestone earnings",
+	})
+}
+
+// CreateCharge collects money from a partner to fund one of their projects
+func CreateCharge(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	if role != "partner" {
+		return c.Status(403).JSON(fiber.Map{"msg": "only partners can fund projects"})
+	}
+
+	type ChargeBody struct {
+		ProjectID uint   `json:"projectId"`
+		Amount    int64  `json:"amount"`
+		Currency  string `json:"currency"`
+		Account   string `json:"account"`
+	}
+	var req ChargeBody
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid payment data: " + err.Error()})
+	}
+	if req.ProjectID == 0 || req.Amount <= 0 || req.Currency == "" || req.Account == "" {
+		return c.Status(400).JSON(fiber.Map{"msg": "projectId, a positive amount, currency and account are required"})
+	}
+
+	var ownerID uint
+	if err := db.Table("projects").Where("id = ? AND deleted_at IS NULL", req.ProjectID).Select("user_id").Scan(&ownerID).Error; err != nil || ownerID == 0 {
+		return c.Status(404).JSON(fiber.Map{"msg": "project not found"})
+	}
+	if ownerID != userID {
+		return c.Status(403).JSON(fiber.Map{"msg": "you can only fund your own projects"})
+	}
+
+	provider, ok := DefaultProvider()
+	if !ok {
+		return c.Status(503).JSON(fiber.Map{"msg": "payment provider is not configured"})
+	}
+
+	key := idempotencyKey(c, "charge", userID)
+	if payment, ok := existingPayment(db, key); ok {
+		return c.JSON(fiber.Map{"msg": "payment already submitted", "data": payment})
+	}
+
+	payment := Payment{
+		Provider:       provider.Name(),
+		IdempotencyKey: key,
+		Kind:           KindCharge,
+		Status:         StatusPending,
+		UserID:         userID,
+		ProjectID:      &req.ProjectID,
+		Account:        req.Account,
+		Currency:       strings.ToUpper(strings.TrimSpace(req.Currency)),
+		Amount:         req.Amount,
+	}
+	if err := db.Create(&payment).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to create payment: " + err.Error()})
+	}
+
+	settled, err := submit(db, provider, &payment)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to process payment: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"msg": "payment submitted", "data": settled})
+}
+
+// CreatePayout sends part of a student's released earnings to their account
+func CreatePayout(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	if role != "student" {
+		return c.Status(403).JSON(fiber.Map{"msg": "only students can request payouts"})
+	}
+
+	type PayoutBody struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+		Account  string `json:"account"`
+	}
+	var req PayoutBody
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid payout data: " + err.Error()})
+	}
+	if req.Amount <= 0 || req.Currency == "" || req.Account == "" {
+		return c.Status(400).JSON(fiber.Map{"msg": "a positive amount, currency and account are required"})
+	}
+
+	provider, ok := DefaultProvider()
+	if !ok {
+		return c.Status(503).JSON(fiber.Map{"msg": "payment provider is not configured"})
+	}
+
+	key := idempotencyKey(c, "payout", userID)
+	if payment, ok := existingPayment(db, key); ok {
+		return c.JSON(fiber.Map{"msg": "payout already submitted", "data": payment})
+	}
+
+	// Take the money off the student's balance before asking the provider to send it
+	payment := Payment{
+		Provider:       provider.Name(),
+		IdempotencyKey: key,
+		Kind:           KindPayout,
+		Status:         StatusPending,
+		UserID:         userID,
+		Account:        req.Account,
+		Currency:       strings.ToUpper(strings.TrimSpace(req.Currency)),
+		Amount:         req.Amount,
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		transaction, err := ledger.Payout(tx, userID, payment.Currency, payment.Amount, userID, "payout to "+payment.Account)
+		if err != nil {
+			return err
+		}
+		payment.LedgerTransactionID = &transaction.ID
+		return tx.Create(&payment).Error
+	}); err != nil {
+		if errors.Is(err, ledger.ErrInsufficientFunds) {
+			return c.Status(400).JSON(fiber.Map{"msg": "your balance does not cover this payout"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to create payout: " + err.Error()})
+	}
+
+	settled, err := submit(db, provider, &payment)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to process payout: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"msg": "payout submitted", "data": settled})
+}
+
+// GetAll lists the caller's payments; super-admins see every payment
+func GetAll(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	query := db.Model(&Payment{})
+	if role != "super-admin" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", strings.ToUpper(kind))
+	}
+
+	var payments []Payment
+	if err := query.Order("created_at DESC").Find(&payments).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get payments: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": payments})
+}
+
+// GetByID returns a payment, asking the provider for its latest status while it is still pending
+func GetByID(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	var payment Payment
+	if err := db.First(&payment, c.Params("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "payment not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get payment: " + err.Error()})
+	}
+	if role != "super-admin" && payment.UserID != userID {
+		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to view this payment"})
+	}
+
+	if payment.Status == StatusPending {
+		provider, ok := GetProvider(payment.Provider)
+		if ok && payment.Reference == nil {
+			// The provider never answered; sending the payment again returns the result of the first attempt
+			settled, err := submit(db, provider, &payment)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"msg": "failed to update payment: " + err.Error()})
+			}
+			payment = *settled
+		} else if ok {
+			if result, err := provider.CheckStatus(*payment.Reference); err == nil && result.Status != StatusPending {
+				var staged []notification.Notification
+				if err := db.Transaction(func(tx *gorm.DB) error {
+					settled, notices, err := applyResult(tx, payment.ID, result.Status, result.FailureReason)
+					if err == nil {
+						payment, staged = *settled, notices
+					}
+					return err
+				}); err != nil {
+					return c.Status(400).JSON(fiber.Map{"msg": "failed to update payment: " + err.Error()})
+				}
+				notification.Dispatch(staged)
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{"data": payment})
+}
+
+// HandleWebhook receives status updates from a provider. Deliveries must carry a valid signature;
+// an event that was already processed is acknowledged without being applied again.
+func HandleWebhook(c *fiber.Ctx, db *gorm.DB) error {
+	provider, ok := GetProvider(c.Params("provider"))
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"msg": "unknown payment provider"})
+	}
+
+	event, err := provider.HandleWebhook(c.Body(), c.Get)
+	if err == ErrInvalidSignature {
+		return c.Status(401).JSON(fiber.Map{"msg": err.Error()})
+	}
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid webhook: " + err.Error()})
+	}
+
+	duplicate := false
+	var staged []notification.Notification
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		record := PaymentWebhookEvent{
+			Provider:  provider.Name(),
+			EventID:   event.EventID,
+			Reference: event.Reference,
+			Status:    event.Status,
+			Payload:   datatypes.JSON(append([]byte(nil), c.Body()...)),
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			duplicate = true
+			return nil
+		}
+
+		var payment Payment
+		if err := tx.Where("provider = ? AND reference = ?", provider.Name(), event.Reference).First(&payment).Error; err != nil {
+			return err
+		}
+		var err error
+		_, staged, err = applyResult(tx, payment.ID, event.Status, event.FailureReason)
+		return err
+	}); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "payment not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to process webhook: " + err.Error()})
+	}
+
+	notification.Dispatch(staged)
+
+	if duplicate {
+		return c.JSON(fiber.Map{"msg": "event already processed"})
+	}
+	return c.JSON(fiber.Map{"msg": "event processed"})
+}
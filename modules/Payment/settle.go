@@ -0,0 +1,81 @@
+package payment
+
+import (
+	"fmt"
+	"log"
+
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
+	notification "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Notification"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// applyResult moves a pending payment to the status reported by its provider and books the money in the
+// ledger. Payments that are already settled are left alone, so the same result can be applied any
+// number of times. It must be called inside a transaction; the returned notifications are staged in it and
+// must be dispatched once it commits.
+func applyResult(tx *gorm.DB, paymentID uint, status, failureReason string) (*Payment, []notification.Notification, error) {
+	var payment Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+		return nil, nil, err
+	}
+	if payment.Status != StatusPending || status == StatusPending {
+		return &payment, nil, nil
+	}
+
+	updates := map[string]interface{}{"status": status}
+	switch {
+	case status == StatusSucceeded && payment.Kind == KindCharge:
+		if payment.ProjectID == nil {
+			return nil, nil, fmt.Errorf("charge %d has no project", payment.ID)
+		}
+		transaction, err := ledger.Fund(tx, *payment.ProjectID, payment.UserID, payment.Currency, payment.Amount, payment.UserID, "payment "+reference(payment))
+		if err != nil {
+			return nil, nil, err
+		}
+		updates["ledger_transaction_id"] = transaction.ID
+	case status == StatusFailed && payment.Kind == KindPayout:
+		// The payout was taken from the student's balance when it was requested
+		if err := ledger.ReversePayout(tx, payment.UserID, payment.Currency, payment.Amount, "payout "+reference(payment)+" failed"); err != nil {
+			return nil, nil, err
+		}
+	}
+	if status == StatusFailed {
+		updates["failure_reason"] = failureReason
+	}
+
+	if err := tx.Model(&payment).Updates(updates).Error; err != nil {
+		return nil, nil, err
+	}
+	payment.Status = status
+	return &payment, notifyResult(tx, payment), nil
+}
+
+// reference returns the provider reference of a payment, or its idempotency key before it has one
+func reference(payment Payment) string {
+	if payment.Reference != nil {
+		return *payment.Reference
+	}
+	return payment.IdempotencyKey
+}
+
+// notifyResult stages a notification telling the payer or payee how their payment ended
+func notifyResult(tx *gorm.DB, payment Payment) []notification.Notification {
+	amount := fmt.Sprintf("%s %d", payment.Currency, payment.Amount)
+	var title, message string
+	switch {
+	case payment.Kind == KindCharge && payment.Status == StatusSucceeded:
+		title, message = "Payment received", "Your payment of "+amount+" has been added to your project's funds."
+	case payment.Kind == KindCharge:
+		title, message = "Payment failed", "Your payment of "+amount+" could not be completed."
+	case payment.Status == StatusSucceeded:
+		title, message = "Payout sent", "Your payout of "+amount+" has been sent."
+	default:
+		title, message = "Payout failed", "Your payout of "+amount+" could not be completed and has been returned to your balance."
+	}
+	staged, err := notification.Stage(tx, []uint{payment.UserID}, "payment", title, message, fmt.Sprintf("/payments/%d", payment.ID))
+	if err != nil {
+		log.Printf("Failed to notify user %d about payment %d: %v", payment.UserID, payment.ID, err)
+	}
+	return staged
+}
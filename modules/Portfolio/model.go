@@ -23,7 +23,8 @@ type PortfolioItem struct {
 	AmountDelivered float64             `json:"amountDelivered"`       // Amount earned
 	Currency        string              `json:"currency" gorm:"default:'USD'"`
 	OnTime          bool                `json:"onTime"`                // Was delivered on time
-	VerifiedAt      datatypes.Date      `json:"verifiedAt"`            // Auto-created timestamp
+	VerifiedAt      datatypes.Date      `json:"verifiedAt"`            // When the system recorded the work; zero for unverified entries
+	Verified        bool                `json:"verified" gorm:"index"` // Created by the platform from approved work; student-posted entries are unverified
 }
 
 
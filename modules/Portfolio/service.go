@@ -3,7 +3,6 @@ package portfolio
 import (
 	"encoding/json"
 	"strconv"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/datatypes"
@@ -35,6 +34,13 @@ func GetAll(c *fiber.Ctx, db *gorm.DB) error {
 		}
 	}
 
+	// Filter by verification (verified=true for platform-recorded work only)
+	if verified := c.Query("verified"); verified != "" {
+		if v, err := strconv.ParseBool(verified); err == nil {
+			query = query.Where("verified = ?", v)
+		}
+	}
+
 	if err := query.Preload("User").Preload("Project").Order("verified_at DESC").Find(&items).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get portfolio items: " + err.Error()})
 	}
@@ -106,7 +112,7 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 		proofJSON, _ = json.Marshal([]string{})
 	}
 
-	// Create portfolio item
+	// Self-reported items stay unverified; verified items are created when milestones are approved
 	item := PortfolioItem{
 		UserID:          userID,
 		ProjectID:       req.ProjectID,
@@ -119,7 +125,7 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 		AmountDelivered: req.AmountDelivered,
 		Currency:        req.Currency,
 		OnTime:          req.OnTime,
-		VerifiedAt:      datatypes.Date(time.Now()),
+		Verified:        false,
 	}
 
 	if err := db.Create(&item).Error; err != nil {
@@ -146,6 +152,10 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get portfolio item: " + err.Error()})
 	}
 
+	if !canChange(c, item) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you can only change your own portfolio items"})
+	}
+
 	type UpdateRequest struct {
 		Role            *string   `json:"role"`
 		Scope           *string   `json:"scope"`
@@ -162,6 +172,11 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "invalid request data: " + err.Error()})
 	}
 
+	// Verified items keep the figures and evidence the partner verified
+	if item.Verified && (req.Role != nil || req.Scope != nil || req.Proof != nil || req.Rating != nil || req.Complexity != nil || req.AmountDelivered != nil || req.Currency != nil || req.OnTime != nil) {
+		return c.Status(400).JSON(fiber.Map{"msg": "a verified portfolio item cannot be changed"})
+	}
+
 	// Update fields
 	if req.Role != nil {
 		item.Role = *req.Role
@@ -217,6 +232,10 @@ func Delete(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get portfolio item: " + err.Error()})
 	}
 
+	if !canChange(c, item) {
+		return c.Status(403).JSON(fiber.Map{"msg": "you can only change your own portfolio items"})
+	}
+
 	if err := db.Delete(&item).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to delete portfolio item: " + err.Error()})
 	}
@@ -224,3 +243,8 @@ func Delete(c *fiber.Ctx, db *gorm.DB) error {
 	return c.JSON(fiber.Map{"msg": "portfolio item deleted successfully"})
 }
 
+// canChange reports whether the caller owns the portfolio item or is a super-admin
+func canChange(c *fiber.Ctx, item PortfolioItem) bool {
+	role, _ := c.Locals("role").(string)
+	return item.UserID == c.Locals("user_id").(uint) || role == "super-admin"
+}
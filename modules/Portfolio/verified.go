@@ -0,0 +1,156 @@
+package portfolio
+
+import (
+	"fmt"
+	"time"
+
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Complexity of approved work, judged by its share of the project budget
+const (
+	highComplexityShare   = 0.4
+	mediumComplexityShare = 0.15
+)
+
+// verifiedRole is the role recorded on entries the platform creates
+const verifiedRole = "Team member"
+
+// ComplexityForShare grades work worth the given share of its project's budget
+func ComplexityForShare(share float64) string {
+	switch {
+	case share >= highComplexityShare:
+		return "HIGH"
+	case share >= mediumComplexityShare:
+		return "MEDIUM"
+	default:
+		return "LOW"
+	}
+}
+
+// ApprovedWork describes an approved milestone to record in the portfolios of the students who did it
+type ApprovedWork struct {
+	ProjectID   uint
+	MilestoneID uint
+	StudentIDs  []uint
+	Scope       string
+	Proof       datatypes.JSON // Files of the approved submission
+	Complexity  string
+	Currency    string
+	OnTime      bool
+	Rating      *float64 // Partner's rating out of 5, if given
+	ApprovedAt  time.Time
+}
+
+// RecordMilestoneApproval creates a verified portfolio entry for each student who delivered an approved
+// milestone. Students who already have one for the milestone keep it. The amount is filled in when the
+// payment is released.
+func RecordMilestoneApproval(tx *gorm.DB, work ApprovedWork) error {
+	proof := work.Proof
+	if len(proof) == 0 {
+		proof = datatypes.JSON("[]")
+	}
+
+	for _, studentID := range work.StudentIDs {
+		var count int64
+		if err := tx.Model(&PortfolioItem{}).
+			Where("user_id = ? AND milestone_id = ? AND verified = ?", studentID, work.MilestoneID, true).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		milestoneID := work.MilestoneID
+		item := PortfolioItem{
+			UserID:      studentID,
+			ProjectID:   work.ProjectID,
+			MilestoneID: &milestoneID,
+			Role:        verifiedRole,
+			Scope:       work.Scope,
+			Proof:       proof,
+			Rating:      work.Rating,
+			Complexity:  work.Complexity,
+			Currency:    work.Currency,
+			OnTime:      work.OnTime,
+			VerifiedAt:  datatypes.Date(work.ApprovedAt),
+			Verified:    true,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordMilestonePayments copies what the release of a milestone paid each student onto their
+// verified entries for it
+func RecordMilestonePayments(tx *gorm.DB, milestoneID uint) error {
+	paid, err := ledger.MilestoneReleases(tx, milestoneID)
+	if err != nil {
+		return err
+	}
+
+	for studentID, payments := range paid {
+		for _, payment := range payments {
+			if err := tx.Model(&PortfolioItem{}).
+				Where("user_id = ? AND milestone_id = ? AND verified = ?", studentID, milestoneID, true).
+				Updates(map[string]interface{}{"amount_delivered": float64(payment.Amount), "currency": payment.Currency}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecordProjectCompletion creates a verified entry for each student assigned to a completed project whose
+// work there is not already recorded milestone by milestone
+func RecordProjectCompletion(tx *gorm.DB, proj project.Project) error {
+	studentIDs, err := project.AssignedStudentIDs(tx, proj.ID)
+	if err != nil {
+		return err
+	}
+
+	paid, err := ledger.ProjectReleases(tx, proj.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, studentID := range studentIDs {
+		var count int64
+		if err := tx.Model(&PortfolioItem{}).
+			Where("user_id = ? AND project_id = ? AND verified = ?", studentID, proj.ID, true).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		item := PortfolioItem{
+			UserID:     studentID,
+			ProjectID:  proj.ID,
+			Role:       verifiedRole,
+			Scope:      fmt.Sprintf("Completed project \"%s\"", proj.Title),
+			Proof:      datatypes.JSON("[]"),
+			Complexity: ComplexityForShare(1),
+			Currency:   proj.BudgetCurrency(),
+			OnTime:     true, // Work approved without milestones has no due dates to miss
+			VerifiedAt: datatypes.Date(time.Now()),
+			Verified:   true,
+		}
+		for _, payment := range paid[studentID] {
+			if payment.Currency == item.Currency {
+				item.AmountDelivered = float64(payment.Amount)
+			}
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
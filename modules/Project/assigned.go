@@ -0,0 +1,52 @@
+package project
+
+import "gorm.io/gorm"
+
+// assignedMemberships selects the members of the groups behind ASSIGNED applications
+func assignedMemberships(db *gorm.DB) *gorm.DB {
+	return db.Table("applications").
+		Joins("JOIN user_groups ON user_groups.group_id = applications.group_id").
+		Where("applications.status = ? AND applications.deleted_at IS NULL", seatStatusAssigned)
+}
+
+// assignedMembers selects the members of the groups assigned to a project
+func assignedMembers(db *gorm.DB, projectID uint) *gorm.DB {
+	return assignedMemberships(db).Where("applications.project_id = ?", projectID)
+}
+
+// AssignedStudentIDs returns the students in the groups assigned to a project, in ID order
+func AssignedStudentIDs(db *gorm.DB, projectID uint) ([]uint, error) {
+	var studentIDs []uint
+	err := assignedMembers(db, projectID).
+		Distinct("user_groups.user_id").
+		Order("user_groups.user_id").
+		Pluck("user_groups.user_id", &studentIDs).Error
+	return studentIDs, err
+}
+
+// IsAssignedStudent reports whether a user belongs to a group assigned to the project
+func IsAssignedStudent(db *gorm.DB, projectID, userID uint) bool {
+	var count int64
+	if err := assignedMembers(db, projectID).Where("user_groups.user_id = ?", userID).Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// AssignedGroupID returns the group through which a user is assigned to the project, if any
+func AssignedGroupID(db *gorm.DB, projectID, userID uint) *uint {
+	var groupIDs []uint
+	assignedMembers(db, projectID).
+		Where("user_groups.user_id = ?", userID).
+		Limit(1).
+		Pluck("applications.group_id", &groupIDs)
+	if len(groupIDs) == 0 {
+		return nil
+	}
+	return &groupIDs[0]
+}
+
+// AssignedProjectIDs is a subquery selecting the projects a user is assigned to through a group
+func AssignedProjectIDs(db *gorm.DB, userID uint) *gorm.DB {
+	return assignedMemberships(db).Select("applications.project_id").Where("user_groups.user_id = ?", userID)
+}
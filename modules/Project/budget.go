@@ -0,0 +1,52 @@
+package project
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// milestoneStatusReleased is the milestone status at which its amount has been paid out
+const milestoneStatusReleased = "RELEASED"
+
+// BudgetSummary describes how much of a project's budget its milestones have claimed.
+// Only milestones in the budget currency are counted.
+type BudgetSummary struct {
+	Currency  string `json:"currency"`
+	Budget    int64  `json:"budget"`
+	Allocated int64  `json:"allocated"`
+	Remaining int64  `json:"remaining"`
+	Released  int64  `json:"released"`
+}
+
+// BudgetCurrency returns the project's budget currency in upper case
+func (p Project) BudgetCurrency() string {
+	return strings.ToUpper(strings.TrimSpace(p.Budget.Currency))
+}
+
+// GetBudgetSummary totals the milestone amounts allocated and released on a project
+func GetBudgetSummary(db *gorm.DB, proj Project) (BudgetSummary, error) {
+	summary := BudgetSummary{
+		Currency: proj.BudgetCurrency(),
+		Budget:   int64(proj.Budget.Value),
+	}
+
+	var totals struct {
+		Allocated int64
+		Released  int64
+	}
+	if err := db.Table("milestones").
+		Select("COALESCE(SUM(amount), 0) AS allocated, COALESCE(SUM(CASE WHEN status = ? THEN amount ELSE 0 END), 0) AS released", milestoneStatusReleased).
+		Where("project_id = ? AND deleted_at IS NULL AND UPPER(currency) = ?", proj.ID, summary.Currency).
+		Scan(&totals).Error; err != nil {
+		return summary, err
+	}
+
+	summary.Allocated = totals.Allocated
+	summary.Released = totals.Released
+	summary.Remaining = summary.Budget - summary.Allocated
+	if summary.Remaining < 0 {
+		summary.Remaining = 0
+	}
+	return summary, nil
+}
@@ -0,0 +1,88 @@
+package project
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Application statuses that hold seats on a project. Offers reserve seats until they are
+// accepted, declined or expire.
+const (
+	seatStatusAssigned = "ASSIGNED"
+	seatStatusOffered  = "OFFERED"
+)
+
+// CapacitySummary describes how many of a project's seats are taken.
+// Capacity is counted in students; a project with Capacity 0 takes a single team of any size.
+type CapacitySummary struct {
+	Capacity       uint  `json:"capacity"`
+	SingleTeam     bool  `json:"singleTeam"`
+	AssignedTeams  int64 `json:"assignedTeams"`
+	AssignedSeats  int64 `json:"assignedSeats"`
+	OfferedTeams   int64 `json:"offeredTeams"`
+	OfferedSeats   int64 `json:"offeredSeats"`
+	RemainingSeats int64 `json:"remainingSeats"`
+	Full           bool  `json:"full"`
+}
+
+// CanSeat reports whether a team of the given size still fits on the project
+func (s CapacitySummary) CanSeat(teamSize int) bool {
+	if s.SingleTeam {
+		return s.AssignedTeams+s.OfferedTeams == 0
+	}
+	return int64(teamSize) <= s.RemainingSeats
+}
+
+// GetCapacitySummary counts the teams and seats assigned or offered on a project
+func GetCapacitySummary(db *gorm.DB, proj Project) (CapacitySummary, error) {
+	summary := CapacitySummary{
+		Capacity:   proj.Capacity,
+		SingleTeam: proj.Capacity == 0,
+	}
+
+	var rows []struct {
+		Status string
+		Teams  int64
+		Seats  int64
+	}
+	if err := db.Table("applications").
+		Select("applications.status, COUNT(DISTINCT applications.id) AS teams, COUNT(application_members.id) AS seats").
+		Joins("LEFT JOIN application_members ON application_members.application_id = applications.id").
+		Where("applications.project_id = ? AND applications.deleted_at IS NULL", proj.ID).
+		Where("applications.status IN ?", []string{seatStatusAssigned, seatStatusOffered}).
+		Group("applications.status").
+		Scan(&rows).Error; err != nil {
+		return summary, err
+	}
+
+	for _, row := range rows {
+		switch row.Status {
+		case seatStatusAssigned:
+			summary.AssignedTeams = row.Teams
+			summary.AssignedSeats = row.Seats
+		case seatStatusOffered:
+			summary.OfferedTeams = row.Teams
+			summary.OfferedSeats = row.Seats
+		}
+	}
+
+	if summary.SingleTeam {
+		summary.Full = summary.AssignedTeams+summary.OfferedTeams > 0
+	} else {
+		summary.RemainingSeats = int64(proj.Capacity) - summary.AssignedSeats - summary.OfferedSeats
+		if summary.RemainingSeats < 0 {
+			summary.RemainingSeats = 0
+		}
+		summary.Full = summary.RemainingSeats == 0
+	}
+
+	return summary, nil
+}
+
+// LockForAssignment loads a project with a row lock so concurrent offers on the same project
+// are serialized. It must be called inside a transaction.
+func LockForAssignment(tx *gorm.DB, projectID uint) (Project, error) {
+	var proj Project
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&proj, projectID).Error
+	return proj, err
+}
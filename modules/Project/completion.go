@@ -0,0 +1,22 @@
+package project
+
+import "gorm.io/gorm"
+
+// completionHooks run in the status update transaction when a project is marked completed
+var completionHooks []func(tx *gorm.DB, proj Project) error
+
+// OnCompleted registers work to do when a project is marked completed, for packages that cannot be
+// imported here (call this from main.go)
+func OnCompleted(hook func(tx *gorm.DB, proj Project) error) {
+	completionHooks = append(completionHooks, hook)
+}
+
+// runCompletionHooks runs the registered completion hooks for a project
+func runCompletionHooks(tx *gorm.DB, proj Project) error {
+	for _, hook := range completionHooks {
+		if err := hook(tx, proj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package project
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	notification "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Notification"
+	"gorm.io/gorm"
+)
+
+// Project statuses used by the deadline scheduler
+const (
+	StatusPublished = "published"
+	StatusClosed    = "closed" // Closed for applications once the deadline passes
+)
+
+// defaultDeadlineSweepIntervalMinutes is used when DEADLINE_SWEEP_INTERVAL_MINUTES is unset or invalid
+const defaultDeadlineSweepIntervalMinutes = 60
+
+// deadlineLayouts are the formats Project.Deadline is accepted in
+var deadlineLayouts = []string{time.RFC3339, "2006-01-02T15:04:05.000Z", "2006-01-02"}
+
+// ParseDeadline parses a project deadline. Date-only deadlines last until the end of that day (UTC).
+func ParseDeadline(value string) (time.Time, error) {
+	for _, layout := range deadlineLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "2006-01-02" {
+				t = t.Add(24*time.Hour - time.Nanosecond)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid deadline %q. Use a date such as 2006-01-02", value)
+}
+
+// validateDeadline checks a deadline sent by the client and returns it trimmed.
+// An empty deadline means the project accepts applications until it is closed by hand.
+func validateDeadline(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	deadline, err := ParseDeadline(value)
+	if err != nil {
+		return "", err
+	}
+	if deadline.Before(time.Now()) {
+		return "", fmt.Errorf("deadline cannot be in the past")
+	}
+	return value, nil
+}
+
+// DeadlinePassed reports whether the project's application deadline is before now.
+// Projects without a readable deadline never close.
+func (p Project) DeadlinePassed(now time.Time) bool {
+	if p.Deadline == "" {
+		return false
+	}
+	deadline, err := ParseDeadline(p.Deadline)
+	if err != nil {
+		return false
+	}
+	return now.After(deadline)
+}
+
+// deadlineSweepInterval returns how often projects past their deadline are closed
+func deadlineSweepInterval() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("DEADLINE_SWEEP_INTERVAL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = defaultDeadlineSweepIntervalMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// applicationSummary describes the applications a project received, e.g. "12 applications (8 SUBMITTED, 4 SHORTLISTED)"
+func applicationSummary(db *gorm.DB, projectID uint) (string, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Table("applications").
+		Select("status, COUNT(*) AS count").
+		Where("project_id = ? AND deleted_at IS NULL", projectID).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return "", err
+	}
+
+	var total int64
+	var parts []string
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Status < rows[j].Status })
+	for _, row := range rows {
+		total += row.Count
+		parts = append(parts, fmt.Sprintf("%d %s", row.Count, row.Status))
+	}
+
+	if total == 0 {
+		return "no applications", nil
+	}
+	noun := "applications"
+	if total == 1 {
+		noun = "application"
+	}
+	return fmt.Sprintf("%d %s (%s)", total, noun, strings.Join(parts, ", ")), nil
+}
+
+// closeExpiredProjects closes published projects whose deadline has passed and sends each partner
+// a summary of the applications received
+func closeExpiredProjects(db *gorm.DB) {
+	var projects []Project
+	if err := db.Where("status = ? AND deadline <> ''", StatusPublished).Find(&projects).Error; err != nil {
+		log.Printf("Deadline scheduler: failed to find published projects: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, proj := range projects {
+		if !proj.DeadlinePassed(now) {
+			continue
+		}
+
+		// Only close the project if nobody changed its status in the meantime
+		result := db.Model(&Project{}).
+			Where("id = ? AND status = ?", proj.ID, StatusPublished).
+			Update("status", StatusClosed)
+		if result.Error != nil {
+			log.Printf("Deadline scheduler: failed to close project %d: %v", proj.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		summary, err := applicationSummary(db, proj.ID)
+		if err != nil {
+			log.Printf("Deadline scheduler: failed to summarize applications for project %d: %v", proj.ID, err)
+			continue
+		}
+		message := fmt.Sprintf("\"%s\" passed its deadline and is now closed for applications. It received %s.", proj.Title, summary)
+		if err := notification.Notify(db, []uint{proj.UserID}, "project_closed", "Project Closed for Applications", message, "/projects"); err != nil {
+			log.Printf("Deadline scheduler: failed to notify partner of project %d: %v", proj.ID, err)
+		}
+	}
+}
+
+// StartDeadlineScheduler starts the background job that closes projects past their deadline (call this from main.go)
+func StartDeadlineScheduler(db *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(deadlineSweepInterval())
+		defer ticker.Stop()
+		for {
+			closeExpiredProjects(db)
+			<-ticker.C
+		}
+	}()
+}
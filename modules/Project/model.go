@@ -64,6 +64,9 @@ type Project struct {
 	Budget                 Budget                `json:"budget" gorm:"embedded;embeddedPrefix:budget_"`
 	Deadline               string                `json:"deadline"`
 	Capacity               uint                  `json:"capacity" gorm:"default:0"`
+	BlindReview            bool                  `json:"blindReview" gorm:"default:false"` // Hide applicant identities from the partner until shortlisting
+	ScreeningQuestions     datatypes.JSON        `json:"screeningQuestions" gorm:"type:json"` // Array of ScreeningQuestion, without preferred options
+	ScreeningPreferences   datatypes.JSON        `json:"-" gorm:"type:json"`                  // Preferred options by question ID; never sent to applicants
 	Status                 string                `json:"status" gorm:"default:'pending'"`
 	Attachments            datatypes.JSON        `json:"attachments" gorm:"type:json"`
 	UserID                 uint                  `json:"userId"`
@@ -73,4 +76,6 @@ type Project struct {
 	PartnerSignature       string                `json:"partnerSignature,omitempty" gorm:"type:text"` // Partner signature data URL
 	UniversityAdminSignature string              `json:"universityAdminSignature,omitempty" gorm:"type:text"` // University admin signature data URL
 	MOUURL                 string                `json:"mouUrl,omitempty" gorm:"type:varchar(500)"` // URL to MOU PDF on Cloudinary
+	Seats                  *CapacitySummary      `json:"seats,omitempty" gorm:"-"`                  // Populated on GET by ID, not stored
+	OverdueMilestones      int64                 `json:"overdueMilestones" gorm:"-"`                // Populated on GET, not stored
 }
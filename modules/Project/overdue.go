@@ -0,0 +1,35 @@
+package project
+
+import "gorm.io/gorm"
+
+// attachOverdueCounts fills in how many milestones of each project the overdue job has flagged
+func attachOverdueCounts(db *gorm.DB, projects []Project) error {
+	if len(projects) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(projects))
+	for i, proj := range projects {
+		ids[i] = proj.ID
+	}
+
+	var rows []struct {
+		ProjectID uint
+		Count     int64
+	}
+	if err := db.Table("milestones").
+		Select("project_id, COUNT(*) AS count").
+		Where("project_id IN ? AND overdue_at IS NOT NULL AND deleted_at IS NULL", ids).
+		Group("project_id").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ProjectID] = row.Count
+	}
+	for i := range projects {
+		projects[i].OverdueMilestones = counts[projects[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Screening question types
+const (
+	QuestionShortText      = "short_text"
+	QuestionMultipleChoice = "multiple_choice"
+	QuestionFile           = "file"
+)
+
+// defaultAnswerMaxLength caps short text answers when a question sets no MaxLength
+const defaultAnswerMaxLength = 2000
+
+// ScreeningQuestion is a question students answer when applying to a project.
+// PreferredOptions marks the multiple choice answers the partner is looking for; they feed the
+// application score. They are stored apart from the questions so applicants never see them.
+type ScreeningQuestion struct {
+	ID               string   `json:"id"`
+	Type             string   `json:"type"`
+	Prompt           string   `json:"prompt"`
+	Required         bool     `json:"required"`
+	Options          []string `json:"options,omitempty"`
+	PreferredOptions []string `json:"preferredOptions,omitempty"`
+	MaxLength        int      `json:"maxLength,omitempty"`
+}
+
+// AnswerMaxLength returns the longest short text answer the question accepts
+func (q ScreeningQuestion) AnswerMaxLength() int {
+	if q.MaxLength > 0 {
+		return q.MaxLength
+	}
+	return defaultAnswerMaxLength
+}
+
+// HasOption reports whether value is one of the question's options
+func (q ScreeningQuestion) HasOption(value string) bool {
+	for _, option := range q.Options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPreferred reports whether value is one of the question's preferred options
+func (q ScreeningQuestion) IsPreferred(value string) bool {
+	for _, option := range q.PreferredOptions {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateScreeningQuestions checks a question schema and returns it encoded for storage: the
+// questions for Project.ScreeningQuestions and their preferred options for
+// Project.ScreeningPreferences. Questions without an ID are numbered q1, q2, ... in order.
+func ValidateScreeningQuestions(questions []ScreeningQuestion) (datatypes.JSON, datatypes.JSON, error) {
+	seen := make(map[string]bool, len(questions))
+	for i := range questions {
+		q := &questions[i]
+		q.ID = strings.TrimSpace(q.ID)
+		if q.ID == "" {
+			q.ID = fmt.Sprintf("q%d", i+1)
+		}
+		if seen[q.ID] {
+			return nil, nil, fmt.Errorf("question id %s is used more than once", q.ID)
+		}
+		seen[q.ID] = true
+
+		q.Prompt = strings.TrimSpace(q.Prompt)
+		if q.Prompt == "" {
+			return nil, nil, fmt.Errorf("question %s needs a prompt", q.ID)
+		}
+		if q.MaxLength < 0 {
+			return nil, nil, fmt.Errorf("question %s has a negative maxLength", q.ID)
+		}
+
+		switch q.Type {
+		case QuestionShortText, QuestionFile:
+			q.Options = nil
+			q.PreferredOptions = nil
+		case QuestionMultipleChoice:
+			if len(q.Options) < 2 {
+				return nil, nil, fmt.Errorf("multiple choice question %s needs at least two options", q.ID)
+			}
+			for _, preferred := range q.PreferredOptions {
+				if !q.HasOption(preferred) {
+					return nil, nil, fmt.Errorf("preferred option %q of question %s is not one of its options", preferred, q.ID)
+				}
+			}
+		default:
+			return nil, nil, fmt.Errorf("question %s has invalid type %q. Must be one of: short_text, multiple_choice, file", q.ID, q.Type)
+		}
+	}
+
+	return splitPreferences(questions)
+}
+
+// splitPreferences encodes questions without their preferred options, and the preferred options by
+// question ID
+func splitPreferences(questions []ScreeningQuestion) (datatypes.JSON, datatypes.JSON, error) {
+	public := make([]ScreeningQuestion, len(questions))
+	preferences := map[string][]string{}
+	for i, q := range questions {
+		if len(q.PreferredOptions) > 0 {
+			preferences[q.ID] = q.PreferredOptions
+		}
+		q.PreferredOptions = nil
+		public[i] = q
+	}
+
+	questionsJSON, err := json.Marshal(public)
+	if err != nil {
+		return nil, nil, err
+	}
+	preferencesJSON, err := json.Marshal(preferences)
+	if err != nil {
+		return nil, nil, err
+	}
+	return datatypes.JSON(questionsJSON), datatypes.JSON(preferencesJSON), nil
+}
+
+// GetScreeningQuestions decodes the project's screening questions together with their preferred options
+func (p Project) GetScreeningQuestions() []ScreeningQuestion {
+	var questions []ScreeningQuestion
+	if len(p.ScreeningQuestions) > 0 {
+		json.Unmarshal(p.ScreeningQuestions, &questions)
+	}
+	var preferences map[string][]string
+	if len(p.ScreeningPreferences) > 0 {
+		json.Unmarshal(p.ScreeningPreferences, &preferences)
+	}
+	for i := range questions {
+		if preferred, ok := preferences[questions[i].ID]; ok {
+			questions[i].PreferredOptions = preferred
+		}
+	}
+	return questions
+}
+
+// showScreeningPreferences puts the preferred options back into the project's screening questions for a
+// response to its owner or admins, who set them
+func (p *Project) showScreeningPreferences() {
+	if len(p.ScreeningQuestions) == 0 {
+		return
+	}
+	if questionsJSON, err := json.Marshal(p.GetScreeningQuestions()); err == nil {
+		p.ScreeningQuestions = datatypes.JSON(questionsJSON)
+	}
+}
+
+// MigrateScreeningPreferences moves preferred options out of screening questions saved before they were
+// stored separately, so they are no longer sent to applicants
+func MigrateScreeningPreferences(db *gorm.DB) error {
+	var projects []Project
+	if err := db.Select("id", "screening_questions", "screening_preferences").
+		Where("screening_questions::text LIKE ?", "%preferredOptions%").
+		Find(&projects).Error; err != nil {
+		return err
+	}
+	for _, proj := range projects {
+		questionsJSON, preferencesJSON, err := splitPreferences(proj.GetScreeningQuestions())
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&Project{}).Where("id = ?", proj.ID).Updates(map[string]interface{}{
+			"screening_questions":   questionsJSON,
+			"screening_preferences": preferencesJSON,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
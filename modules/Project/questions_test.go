@@ -0,0 +1,55 @@
+package project
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScreeningPreferencesAreStoredApart(t *testing.T) {
+	questions := []ScreeningQuestion{
+		{Type: QuestionMultipleChoice, Prompt: "Preferred stack?", Options: []string{"Go", "PHP", "Rust"}, PreferredOptions: []string{"Go", "Rust"}},
+		{Type: QuestionShortText, Prompt: "Why this project?"},
+		{Type: QuestionMultipleChoice, Prompt: "Available full time?", Options: []string{"Yes", "No"}},
+	}
+
+	questionsJSON, preferencesJSON, err := ValidateScreeningQuestions(questions)
+	if err != nil {
+		t.Fatalf("ValidateScreeningQuestions() error = %v", err)
+	}
+	if strings.Contains(string(questionsJSON), "preferredOptions") {
+		t.Errorf("stored questions carry preferred options: %s", questionsJSON)
+	}
+
+	proj := Project{ScreeningQuestions: questionsJSON, ScreeningPreferences: preferencesJSON}
+	tests := []struct {
+		id, value string
+		want      bool
+	}{
+		{"q1", "Go", true},
+		{"q1", "Rust", true},
+		{"q1", "PHP", false},
+		{"q3", "Yes", false},
+	}
+	decoded := proj.GetScreeningQuestions()
+	for _, tt := range tests {
+		for _, q := range decoded {
+			if q.ID == tt.id && q.IsPreferred(tt.value) != tt.want {
+				t.Errorf("question %s IsPreferred(%q) = %v, want %v", tt.id, tt.value, !tt.want, tt.want)
+			}
+		}
+	}
+
+	proj.showScreeningPreferences()
+	if !strings.Contains(string(proj.ScreeningQuestions), `"preferredOptions":["Go","Rust"]`) {
+		t.Errorf("owner view does not show preferred options: %s", proj.ScreeningQuestions)
+	}
+}
+
+func TestGetScreeningQuestionsReadsLegacyPreferences(t *testing.T) {
+	// Questions saved before preferences were stored apart keep working until they are migrated
+	proj := Project{ScreeningQuestions: []byte(`[{"id":"q1","type":"multiple_choice","prompt":"Stack?","options":["Go","PHP"],"preferredOptions":["Go"]}]`)}
+	questions := proj.GetScreeningQuestions()
+	if len(questions) != 1 || !questions[0].IsPreferred("Go") {
+		t.Errorf("GetScreeningQuestions() = %+v", questions)
+	}
+}
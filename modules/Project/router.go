@@ -29,6 +29,10 @@ func RegisterRoutes(r fiber.Router, db *gorm.DB) {
 		return UpdateStatus(c, db)
 	})
 
+	projects.Put("/blind-review", func(c *fiber.Ctx) error {
+		return UpdateBlindReview(c, db)
+	})
+
 	projects.Put("/assign-supervisor", func(c *fiber.Ctx) error {
 		return AssignSupervisor(c, db)
 	})
@@ -11,6 +11,7 @@ import (
 
 	course "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Course"
 	department "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Department"
+	ledger "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Ledger"
 	organization "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Organization"
 	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
 	"github.com/gofiber/fiber/v2"
@@ -43,26 +44,27 @@ func ValidateTeamStructure(teamStructure string) bool {
 func Create(c *fiber.Ctx, db *gorm.DB) error {
 	// Parse request body - frontend sends camelCase field names
 	type CreateRequest struct {
-		DepartmentID           *int     `json:"departmentId,omitempty"`
-		CourseID               *int     `json:"courseId,omitempty"`
-		UniversityID           *uint    `json:"universityId,omitempty"`
-		Title                  string   `json:"title"`
-		Description            string   `json:"description"` // Kept for backward compatibility
-		Summary                string   `json:"summary,omitempty"`
-		ChallengeStatement     string   `json:"challengeStatement,omitempty"`
-		ScopeActivities        string   `json:"scopeActivities,omitempty"`
-		DeliverablesMilestones string   `json:"deliverablesMilestones,omitempty"`
-		TeamStructure          string   `json:"teamStructure,omitempty"`
-		Duration               string   `json:"duration,omitempty"`
-		Expectations           string   `json:"expectations,omitempty"`
-		Skills                 []string `json:"skills"`
-		BudgetValue            *float64 `json:"budget,omitempty"`   // Frontend sends budget as number
-		Currency               *string  `json:"currency,omitempty"` // Frontend sends currency separately
-		Deadline               string   `json:"deadline"`
-		Capacity               uint     `json:"capacity"`
-		Status                 string   `json:"status"`
-		Attachments            []string `json:"attachments"`
-		PartnerSignature       string   `json:"partnerSignature,omitempty"` // Partner signature data URL
+		DepartmentID           *int                `json:"departmentId,omitempty"`
+		CourseID               *int                `json:"courseId,omitempty"`
+		UniversityID           *uint               `json:"universityId,omitempty"`
+		Title                  string              `json:"title"`
+		Description            string              `json:"description"` // Kept for backward compatibility
+		Summary                string              `json:"summary,omitempty"`
+		ChallengeStatement     string              `json:"challengeStatement,omitempty"`
+		ScopeActivities        string              `json:"scopeActivities,omitempty"`
+		DeliverablesMilestones string              `json:"deliverablesMilestones,omitempty"`
+		TeamStructure          string              `json:"teamStructure,omitempty"`
+		Duration               string              `json:"duration,omitempty"`
+		Expectations           string              `json:"expectations,omitempty"`
+		Skills                 []string            `json:"skills"`
+		BudgetValue            *float64            `json:"budget,omitempty"`   // Frontend sends budget as number
+		Currency               *string             `json:"currency,omitempty"` // Frontend sends currency separately
+		Deadline               string              `json:"deadline"`
+		Capacity               uint                `json:"capacity"`
+		Status                 string              `json:"status"`
+		Attachments            []string            `json:"attachments"`
+		PartnerSignature       string              `json:"partnerSignature,omitempty"` // Partner signature data URL
+		ScreeningQuestions     []ScreeningQuestion `json:"screeningQuestions,omitempty"`
 	}
 
 	var req CreateRequest
@@ -76,6 +78,12 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "invalid team structure. Must be one of: individuals, groups, both"})
 	}
 
+	// Validate the application deadline
+	deadline, err := validateDeadline(req.Deadline)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+	}
+
 	// Build project from request
 	project := Project{
 		Title:                  req.Title,
@@ -87,7 +95,7 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 		TeamStructure:          req.TeamStructure,
 		Duration:               req.Duration,
 		Expectations:           req.Expectations,
-		Deadline:               req.Deadline,
+		Deadline:               deadline,
 		Capacity:               req.Capacity,
 		Status:                 req.Status,
 		UserID:                 c.Locals("user_id").(uint),
@@ -115,6 +123,16 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 		project.Skills = datatypes.JSON(skillsJSON)
 	}
 
+	// Handle screening questions - validate the schema and store it as JSON
+	if len(req.ScreeningQuestions) > 0 {
+		questionsJSON, preferencesJSON, err := ValidateScreeningQuestions(req.ScreeningQuestions)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "invalid screening questions: " + err.Error()})
+		}
+		project.ScreeningQuestions = questionsJSON
+		project.ScreeningPreferences = preferencesJSON
+	}
+
 	// Handle attachments - convert to JSON
 	if len(req.Attachments) > 0 {
 		attachmentsJSON, err := json.Marshal(req.Attachments)
@@ -186,6 +204,7 @@ func Create(c *fiber.Ctx, db *gorm.DB) error {
 	if err := db.Preload("User").Preload("Supervisor").Preload("Department").Preload("Department.Organization").Preload("Course").First(&project, project.ID).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "project created but failed to load details: " + err.Error()})
 	}
+	project.showScreeningPreferences()
 
 	data := fiber.Map{
 		"msg":  "project created successfully",
@@ -202,6 +221,12 @@ func GetByOwner(c *fiber.Ctx, db *gorm.DB) error {
 	if err := db.Where("user_id = ?", c.Locals("user_id").(uint)).Preload("Department").Preload("Department.Organization").Preload("Course").Preload("User").Preload("Supervisor").Find(&projects).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get projects: " + err.Error()})
 	}
+	if err := attachOverdueCounts(db, projects); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to count overdue milestones: " + err.Error()})
+	}
+	for i := range projects {
+		projects[i].showScreeningPreferences()
+	}
 
 	return c.JSON(fiber.Map{"data": projects})
 
@@ -210,26 +235,27 @@ func GetByOwner(c *fiber.Ctx, db *gorm.DB) error {
 func Update(c *fiber.Ctx, db *gorm.DB) error {
 	// Parse request body - frontend sends camelCase field names
 	type UpdateRequest struct {
-		ID                     *uint    `json:"id"`
-		DepartmentID           *int     `json:"departmentId,omitempty"`
-		CourseID               *int     `json:"courseId,omitempty"`
-		UniversityID           *uint    `json:"universityId,omitempty"`
-		Title                  *string  `json:"title,omitempty"`
-		Description            *string  `json:"description,omitempty"`
-		Summary                *string  `json:"summary,omitempty"`
-		ChallengeStatement     *string  `json:"challengeStatement,omitempty"`
-		ScopeActivities        *string  `json:"scopeActivities,omitempty"`
-		DeliverablesMilestones *string  `json:"deliverablesMilestones,omitempty"`
-		TeamStructure          *string  `json:"teamStructure,omitempty"`
-		Duration               *string  `json:"duration,omitempty"`
-		Expectations           *string  `json:"expectations,omitempty"`
-		Skills                 []string `json:"skills,omitempty"`
-		BudgetValue            *float64 `json:"budget,omitempty"`
-		Currency               *string  `json:"currency,omitempty"`
-		Deadline               *string  `json:"deadline,omitempty"`
-		Capacity               *uint    `json:"capacity,omitempty"`
-		Status                 *string  `json:"status,omitempty"`
-		Attachments            []string `json:"attachments,omitempty"`
+		ID                     *uint               `json:"id"`
+		DepartmentID           *int                `json:"departmentId,omitempty"`
+		CourseID               *int                `json:"courseId,omitempty"`
+		UniversityID           *uint               `json:"universityId,omitempty"`
+		Title                  *string             `json:"title,omitempty"`
+		Description            *string             `json:"description,omitempty"`
+		Summary                *string             `json:"summary,omitempty"`
+		ChallengeStatement     *string             `json:"challengeStatement,omitempty"`
+		ScopeActivities        *string             `json:"scopeActivities,omitempty"`
+		DeliverablesMilestones *string             `json:"deliverablesMilestones,omitempty"`
+		TeamStructure          *string             `json:"teamStructure,omitempty"`
+		Duration               *string             `json:"duration,omitempty"`
+		Expectations           *string             `json:"expectations,omitempty"`
+		Skills                 []string            `json:"skills,omitempty"`
+		BudgetValue            *float64            `json:"budget,omitempty"`
+		Currency               *string             `json:"currency,omitempty"`
+		Deadline               *string             `json:"deadline,omitempty"`
+		Capacity               *uint               `json:"capacity,omitempty"`
+		Status                 *string             `json:"status,omitempty"`
+		Attachments            []string            `json:"attachments,omitempty"`
+		ScreeningQuestions     []ScreeningQuestion `json:"screeningQuestions,omitempty"`
 	}
 
 	var req UpdateRequest
@@ -291,10 +317,24 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 	if req.Expectations != nil {
 		project.Expectations = *req.Expectations
 	}
-	if req.Deadline != nil {
-		project.Deadline = *req.Deadline
+	if req.Deadline != nil && *req.Deadline != project.Deadline {
+		deadline, err := validateDeadline(*req.Deadline)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": err.Error()})
+		}
+		project.Deadline = deadline
 	}
 	if req.Capacity != nil {
+		// Capacity cannot drop below the seats already assigned or offered
+		if *req.Capacity > 0 {
+			seats, err := GetCapacitySummary(db, project)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"msg": "failed to check project capacity: " + err.Error()})
+			}
+			if int64(*req.Capacity) < seats.AssignedSeats+seats.OfferedSeats {
+				return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("capacity cannot be lower than the %d seats already assigned or offered", seats.AssignedSeats+seats.OfferedSeats)})
+			}
+		}
 		project.Capacity = *req.Capacity
 	}
 	if req.Status != nil {
@@ -352,6 +392,16 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 		project.Skills = datatypes.JSON(skillsJSON)
 	}
 
+	// Handle screening questions - validate the schema and store it as JSON
+	if req.ScreeningQuestions != nil {
+		questionsJSON, preferencesJSON, err := ValidateScreeningQuestions(req.ScreeningQuestions)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "invalid screening questions: " + err.Error()})
+		}
+		project.ScreeningQuestions = questionsJSON
+		project.ScreeningPreferences = preferencesJSON
+	}
+
 	// Handle attachments - convert to JSON
 	if req.Attachments != nil {
 		attachmentsJSON, err := json.Marshal(req.Attachments)
@@ -362,6 +412,7 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 	}
 
 	// Handle budget - frontend sends budget as number and currency separately
+	previousCurrency := project.Budget.Currency
 	if req.BudgetValue != nil && req.Currency != nil {
 		project.Budget = Budget{
 			Currency: *req.Currency,
@@ -380,6 +431,20 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 		if project.Budget.Currency == "" || project.Budget.Value == 0 {
 			return c.Status(400).JSON(fiber.Map{"msg": "budget and currency are required"})
 		}
+
+		// The budget must still cover the milestones already planned against it
+		var milestoneCount int64
+		db.Table("milestones").Where("project_id = ? AND deleted_at IS NULL", project.ID).Count(&milestoneCount)
+		if milestoneCount > 0 && !strings.EqualFold(project.Budget.Currency, previousCurrency) {
+			return c.Status(400).JSON(fiber.Map{"msg": "budget currency cannot change once milestones have been created"})
+		}
+		budget, err := GetBudgetSummary(db, project)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "failed to check milestone allocations: " + err.Error()})
+		}
+		if budget.Allocated > budget.Budget {
+			return c.Status(400).JSON(fiber.Map{"msg": fmt.Sprintf("budget cannot be lower than the %d %s already allocated to milestones", budget.Allocated, budget.Currency)})
+		}
 	}
 
 	// Update project
@@ -391,6 +456,7 @@ func Update(c *fiber.Ctx, db *gorm.DB) error {
 	if err := db.Preload("Department").Preload("Department.Organization").Preload("Course").Preload("User").Preload("Supervisor").First(&project, project.ID).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "project updated but failed to load details: " + err.Error()})
 	}
+	project.showScreeningPreferences()
 
 	return c.JSON(fiber.Map{"data": project})
 }
@@ -461,10 +527,10 @@ func UpdateStatus(c *fiber.Ctx, db *gorm.DB) error {
 	validStatuses := map[string]bool{
 		"draft": true, "published": true, "in-progress": true,
 		"on-hold": true, "completed": true, "cancelled": true,
-		"pending": true, "suspended": true,
+		"pending": true, "suspended": true, "closed": true,
 	}
 	if !validStatuses[status] {
-		return c.Status(400).JSON(fiber.Map{"msg": "invalid status. Must be one of: draft, published, in-progress, on-hold, completed, cancelled, pending, suspended"})
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid status. Must be one of: draft, published, in-progress, on-hold, completed, cancelled, pending, suspended, closed"})
 	}
 
 	// If approving (status = "published") and university-admin, require signature
@@ -497,7 +563,21 @@ func UpdateStatus(c *fiber.Ctx, db *gorm.DB) error {
 		updates["university_admin_signature"] = ""
 	}
 
-	if err := db.Model(&tmp).Updates(updates).Error; err != nil {
+	// Cancelling a project returns its escrow and unspent funds to the partner
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if status == "cancelled" && tmp.Status != "cancelled" {
+			if err := ledger.RefundProject(tx, tmp.ID, tmp.UserID, userID); err != nil {
+				return err
+			}
+		}
+		// Completing a project records the students' work in their portfolios
+		if status == "completed" && tmp.Status != "completed" {
+			if err := runCompletionHooks(tx, tmp); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&tmp).Updates(updates).Error
+	}); err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to update project status"})
 	}
 
@@ -509,6 +589,50 @@ func UpdateStatus(c *fiber.Ctx, db *gorm.DB) error {
 	return c.JSON(fiber.Map{"data": tmp})
 }
 
+// UpdateBlindReview turns blind review on or off for a project (university admin action).
+// While it is on, partners see anonymized applicants until they are shortlisted.
+func UpdateBlindReview(c *fiber.Ctx, db *gorm.DB) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	type BlindReviewRequest struct {
+		ProjectID   uint `json:"projectId"`
+		BlindReview bool `json:"blindReview"`
+	}
+	var req BlindReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid input"})
+	}
+
+	var proj Project
+	if err := db.Preload("Department").First(&proj, req.ProjectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "project not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load project: " + err.Error()})
+	}
+
+	// Only the project's university admins (and super-admins) decide how partners review applicants
+	if role != "super-admin" {
+		if role != "university-admin" && role != "delegated-admin" {
+			return c.Status(403).JSON(fiber.Map{"msg": "only university admins can change blind review"})
+		}
+		userOrgID, err := adminOrganizationID(db, userID, role)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"msg": "failed to get user organization"})
+		}
+		if proj.Department.OrganizationID != userOrgID {
+			return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to update this project"})
+		}
+	}
+
+	if err := db.Model(&proj).Update("blind_review", req.BlindReview).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to update blind review: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"msg": "blind review updated successfully", "data": proj})
+}
+
 func AssignSupervisor(c *fiber.Ctx, db *gorm.DB) error {
 
 	type Body struct {
@@ -645,6 +769,9 @@ func GetAll(c *fiber.Ctx, db *gorm.DB) error {
 	if err := query.Preload("User").Preload("Supervisor").Preload("Department").Preload("Department.Organization").Preload("Course").Find(&projects).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get projects: " + err.Error()})
 	}
+	if err := attachOverdueCounts(db, projects); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to count overdue milestones: " + err.Error()})
+	}
 
 	return c.JSON(fiber.Map{
 		"data":       projects,
@@ -655,6 +782,37 @@ func GetAll(c *fiber.Ctx, db *gorm.DB) error {
 	})
 }
 
+// adminOrganizationID returns the organization a university or delegated admin acts for
+func adminOrganizationID(db *gorm.DB, userID uint, role string) (uint, error) {
+	var userOrgID uint
+	var err error
+	if role == "delegated-admin" {
+		err = db.Table("delegated_accesses").
+			Where("delegated_user_id = ? AND is_active = ?", userID, true).
+			Select("organization_id").
+			Scan(&userOrgID).Error
+	} else {
+		err = db.Table("organizations").
+			Where("user_id = ?", userID).
+			Select("id").
+			Scan(&userOrgID).Error
+	}
+	return userOrgID, err
+}
+
+// canSeeScreeningPreferences reports whether the caller may see a project's preferred screening answers:
+// its owner, super-admins and admins of its university. The project must be loaded with its Department.
+func canSeeScreeningPreferences(db *gorm.DB, proj Project, userID uint, role string) bool {
+	switch role {
+	case "super-admin":
+		return true
+	case "university-admin", "delegated-admin":
+		userOrgID, err := adminOrganizationID(db, userID, role)
+		return err == nil && proj.Department.OrganizationID == userOrgID
+	}
+	return proj.UserID == userID
+}
+
 // GetByID retrieves a project by ID
 func GetByID(c *fiber.Ctx, db *gorm.DB) error {
 	id := c.Params("id")
@@ -667,6 +825,26 @@ func GetByID(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to get project: " + err.Error()})
 	}
 
+	// Attach the capacity/seat summary
+	seats, err := GetCapacitySummary(db, proj)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get project capacity: " + err.Error()})
+	}
+	proj.Seats = &seats
+
+	overdue := []Project{proj}
+	if err := attachOverdueCounts(db, overdue); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to count overdue milestones: " + err.Error()})
+	}
+	proj = overdue[0]
+
+	// Preferred screening answers feed the score, so only the people who set them may see them
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+	if canSeeScreeningPreferences(db, proj, userID, role) {
+		proj.showScreeningPreferences()
+	}
+
 	return c.JSON(fiber.Map{"data": proj})
 }
 
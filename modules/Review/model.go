@@ -0,0 +1,47 @@
+package review
+
+import (
+	"time"
+
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"gorm.io/gorm"
+)
+
+// Review directions
+const (
+	DirectionPartnerToStudent = "PARTNER_TO_STUDENT"
+	DirectionStudentToPartner = "STUDENT_TO_PARTNER"
+)
+
+// Review statuses. Disputed reviews stay visible but do not count towards ratings until a moderator
+// upholds them; removed reviews are hidden.
+const (
+	StatusPublished = "PUBLISHED"
+	StatusDisputed  = "DISPUTED"
+	StatusRemoved   = "REMOVED"
+)
+
+// Review is one participant's rating of another after a project is completed.
+// Each reviewer can review each reviewee once per project.
+type Review struct {
+	gorm.Model
+	ProjectID      uint            `json:"projectId" gorm:"uniqueIndex:idx_review_pair;not null"`
+	Project        project.Project `json:"project" gorm:"foreignKey:ProjectID"`
+	ReviewerID     uint            `json:"reviewerId" gorm:"uniqueIndex:idx_review_pair;not null"`
+	Reviewer       user.User       `json:"reviewer" gorm:"foreignKey:ReviewerID"`
+	RevieweeID     uint            `json:"revieweeId" gorm:"uniqueIndex:idx_review_pair;index;not null"`
+	Reviewee       user.User       `json:"reviewee" gorm:"foreignKey:RevieweeID"`
+	Direction      string          `json:"direction"`     // PARTNER_TO_STUDENT, STUDENT_TO_PARTNER
+	Quality        int             `json:"quality"`       // 1-5
+	Communication  int             `json:"communication"` // 1-5
+	Timeliness     int             `json:"timeliness"`    // 1-5
+	Overall        float64         `json:"overall"`       // Average of the criteria
+	Comment        string          `json:"comment" gorm:"type:text"`
+	Status         string          `json:"status" gorm:"default:'PUBLISHED';index"` // PUBLISHED, DISPUTED, REMOVED
+	DisputeReason  string          `json:"disputeReason,omitempty" gorm:"type:text"`
+	DisputedAt     *time.Time      `json:"disputedAt,omitempty"`
+	ModeratedByID  *uint           `json:"moderatedById,omitempty"`
+	ModeratedAt    *time.Time      `json:"moderatedAt,omitempty"`
+	ModerationNote string          `json:"moderationNote,omitempty" gorm:"type:text"`
+}
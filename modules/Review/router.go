@@ -0,0 +1,40 @@
+package review
+
+import (
+	user "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/User"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func RegisterRoutes(r fiber.Router, db *gorm.DB) {
+	reviews := r.Group("/reviews", user.JWTProtect([]string{"student", "partner", "supervisor", "university-admin", "delegated-admin", "super-admin"}))
+
+	reviews.Get("/", func(c *fiber.Ctx) error {
+		return GetAll(c, db)
+	})
+
+	reviews.Post("/", func(c *fiber.Ctx) error {
+		return Create(c, db)
+	})
+
+	// Moderation queue and profile reviews (must come before /:id)
+	reviews.Get("/disputed", func(c *fiber.Ctx) error {
+		return GetDisputed(c, db)
+	})
+
+	reviews.Get("/users/:id", func(c *fiber.Ctx) error {
+		return GetForUser(c, db)
+	})
+
+	reviews.Get("/:id", func(c *fiber.Ctx) error {
+		return GetByID(c, db)
+	})
+
+	reviews.Post("/:id/dispute", func(c *fiber.Ctx) error {
+		return Dispute(c, db)
+	})
+
+	reviews.Post("/:id/moderate", func(c *fiber.Ctx) error {
+		return Moderate(c, db)
+	})
+}
@@ -0,0 +1,338 @@
+package review
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	notification "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Notification"
+	project "github.com/BVR-INNOVATION-GROUP/strike-force-backend/modules/Project"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// superAdminIDs returns the users who moderate reviews
+func superAdminIDs(db *gorm.DB) []uint {
+	var ids []uint
+	db.Table("users").Where("role = ? AND deleted_at IS NULL", "super-admin").Pluck("id", &ids)
+	return ids
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func validCriterion(v int) bool {
+	return v >= 1 && v <= 5
+}
+
+// Create records a review of a team member by the project's partner, or of the partner by a team member,
+// once the project is completed
+func Create(c *fiber.Ctx, db *gorm.DB) error {
+	type CreateRequest struct {
+		ProjectID     uint   `json:"projectId"`
+		RevieweeID    uint   `json:"revieweeId"` // Optional for students, who review the project's partner
+		Quality       int    `json:"quality"`
+		Communication int    `json:"communication"`
+		Timeliness    int    `json:"timeliness"`
+		Comment       string `json:"comment"`
+	}
+
+	var req CreateRequest
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid request data: " + err.Error()})
+	}
+
+	if req.ProjectID == 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "projectId is required"})
+	}
+	if !validCriterion(req.Quality) || !validCriterion(req.Communication) || !validCriterion(req.Timeliness) {
+		return c.Status(400).JSON(fiber.Map{"msg": "quality, communication and timeliness must be between 1 and 5"})
+	}
+
+	var proj project.Project
+	if err := db.First(&proj, req.ProjectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "project not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get project: " + err.Error()})
+	}
+	if proj.Status != "completed" {
+		return c.Status(400).JSON(fiber.Map{"msg": "reviews can only be left once the project is completed"})
+	}
+
+	students, err := project.AssignedStudentIDs(db, proj.ID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get the project's students: " + err.Error()})
+	}
+	var direction string
+	switch role {
+	case "partner":
+		if proj.UserID != userID {
+			return c.Status(403).JSON(fiber.Map{"msg": "you can only review the team on your own projects"})
+		}
+		if !containsID(students, req.RevieweeID) {
+			return c.Status(400).JSON(fiber.Map{"msg": "revieweeId must be a student assigned to the project"})
+		}
+		direction = DirectionPartnerToStudent
+	case "student":
+		if !containsID(students, userID) {
+			return c.Status(403).JSON(fiber.Map{"msg": "you can only review partners of projects you worked on"})
+		}
+		if req.RevieweeID != 0 && req.RevieweeID != proj.UserID {
+			return c.Status(400).JSON(fiber.Map{"msg": "students can only review the project's partner"})
+		}
+		req.RevieweeID = proj.UserID
+		direction = DirectionStudentToPartner
+	default:
+		return c.Status(403).JSON(fiber.Map{"msg": "only partners and students can leave reviews"})
+	}
+
+	var existing int64
+	db.Model(&Review{}).
+		Where("project_id = ? AND reviewer_id = ? AND reviewee_id = ?", proj.ID, userID, req.RevieweeID).
+		Count(&existing)
+	if existing > 0 {
+		return c.Status(400).JSON(fiber.Map{"msg": "you have already reviewed this user for this project"})
+	}
+
+	review := Review{
+		ProjectID:     proj.ID,
+		ReviewerID:    userID,
+		RevieweeID:    req.RevieweeID,
+		Direction:     direction,
+		Quality:       req.Quality,
+		Communication: req.Communication,
+		Timeliness:    req.Timeliness,
+		Overall:       float64(req.Quality+req.Communication+req.Timeliness) / 3,
+		Comment:       strings.TrimSpace(req.Comment),
+		Status:        StatusPublished,
+	}
+
+	if err := db.Create(&review).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to create review: " + err.Error()})
+	}
+
+	message := fmt.Sprintf("You received a review for \"%s\"", proj.Title)
+	notification.Notify(db, []uint{review.RevieweeID}, "review", "New Review", message, fmt.Sprintf("/reviews/%d", review.ID))
+
+	if err := db.Preload("Reviewer").Preload("Reviewee").Preload("Project").First(&review, review.ID).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to load review details: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{"data": review})
+}
+
+// GetAll lists visible reviews, filtered by projectId, revieweeId or reviewerId
+func GetAll(c *fiber.Ctx, db *gorm.DB) error {
+	var reviews []Review
+	query := db.Model(&Review{}).Where("status <> ?", StatusRemoved)
+
+	for param, column := range map[string]string{"projectId": "project_id", "revieweeId": "reviewee_id", "reviewerId": "reviewer_id"} {
+		if value := c.Query(param); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"msg": "invalid " + param})
+			}
+			query = query.Where(column+" = ?", uint(id))
+		}
+	}
+
+	if err := query.Preload("Reviewer").Preload("Reviewee").Preload("Project").Order("created_at DESC").Find(&reviews).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get reviews: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": reviews})
+}
+
+// GetByID retrieves a review. Removed reviews are only visible to moderators.
+func GetByID(c *fiber.Ctx, db *gorm.DB) error {
+	id := c.Params("id")
+	role, _ := c.Locals("role").(string)
+	var review Review
+
+	if err := db.Preload("Reviewer").Preload("Reviewee").Preload("Project").First(&review, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "review not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get review: " + err.Error()})
+	}
+	if review.Status == StatusRemoved && role != "super-admin" {
+		return c.Status(404).JSON(fiber.Map{"msg": "review not found"})
+	}
+
+	return c.JSON(fiber.Map{"data": review})
+}
+
+// GetForUser returns the reviews a user has received with their averages, for display on the user's profile
+func GetForUser(c *fiber.Ctx, db *gorm.DB) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid user id"})
+	}
+	userID := uint(id)
+
+	var reviews []Review
+	if err := db.Where("reviewee_id = ? AND status <> ?", userID, StatusRemoved).
+		Preload("Reviewer").
+		Preload("Project").
+		Order("created_at DESC").
+		Find(&reviews).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get reviews: " + err.Error()})
+	}
+
+	asStudent, err := SummaryFor(db, []uint{userID}, DirectionPartnerToStudent)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to summarise reviews: " + err.Error()})
+	}
+	asPartner, err := SummaryFor(db, []uint{userID}, DirectionStudentToPartner)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to summarise reviews: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": fiber.Map{
+		"reviews":   reviews,
+		"asStudent": asStudent,
+		"asPartner": asPartner,
+	}})
+}
+
+// Dispute lets the reviewee flag a review for moderation. The review stops counting towards their
+// ratings until a moderator decides.
+func Dispute(c *fiber.Ctx, db *gorm.DB) error {
+	id := c.Params("id")
+	userID := c.Locals("user_id").(uint)
+
+	type DisputeRequest struct {
+		Reason string `json:"reason"`
+	}
+	var req DisputeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid request data: " + err.Error()})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.Status(400).JSON(fiber.Map{"msg": "reason is required"})
+	}
+
+	var review Review
+	if err := db.First(&review, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "review not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get review: " + err.Error()})
+	}
+	if review.RevieweeID != userID {
+		return c.Status(403).JSON(fiber.Map{"msg": "you can only dispute reviews about you"})
+	}
+	if review.Status != StatusPublished || review.ModeratedAt != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "this review cannot be disputed"})
+	}
+
+	now := time.Now()
+	if err := db.Model(&review).Updates(map[string]interface{}{
+		"status":         StatusDisputed,
+		"dispute_reason": req.Reason,
+		"disputed_at":    now,
+	}).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to dispute review: " + err.Error()})
+	}
+
+	db.Preload("Reviewer").Preload("Reviewee").Preload("Project").First(&review, review.ID)
+
+	message := fmt.Sprintf("Review #%d has been disputed: %s", review.ID, req.Reason)
+	notification.Notify(db, superAdminIDs(db), "review_disputed", "Review Disputed", message, fmt.Sprintf("/reviews/%d", review.ID))
+
+	return c.JSON(fiber.Map{"data": review})
+}
+
+// GetDisputed lists reviews awaiting moderation
+func GetDisputed(c *fiber.Ctx, db *gorm.DB) error {
+	role, _ := c.Locals("role").(string)
+	if role != "super-admin" {
+		return c.Status(403).JSON(fiber.Map{"msg": "only super admins can moderate reviews"})
+	}
+
+	var reviews []Review
+	if err := db.Where("status = ?", StatusDisputed).
+		Preload("Reviewer").
+		Preload("Reviewee").
+		Preload("Project").
+		Order("disputed_at").
+		Find(&reviews).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get disputed reviews: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": reviews})
+}
+
+// Moderate resolves a disputed review by upholding it (it counts again) or removing it
+func Moderate(c *fiber.Ctx, db *gorm.DB) error {
+	id := c.Params("id")
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+	if role != "super-admin" {
+		return c.Status(403).JSON(fiber.Map{"msg": "only super admins can moderate reviews"})
+	}
+
+	type ModerateRequest struct {
+		Action string `json:"action"` // uphold, remove
+		Note   string `json:"note"`
+	}
+	var req ModerateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "invalid request data: " + err.Error()})
+	}
+
+	var status string
+	switch req.Action {
+	case "uphold":
+		status = StatusPublished
+	case "remove":
+		status = StatusRemoved
+	default:
+		return c.Status(400).JSON(fiber.Map{"msg": "action must be uphold or remove"})
+	}
+
+	var review Review
+	if err := db.First(&review, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{"msg": "review not found"})
+		}
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to get review: " + err.Error()})
+	}
+	if review.Status != StatusDisputed {
+		return c.Status(400).JSON(fiber.Map{"msg": "only disputed reviews can be moderated"})
+	}
+
+	now := time.Now()
+	if err := db.Model(&review).Updates(map[string]interface{}{
+		"status":          status,
+		"moderated_by_id": userID,
+		"moderated_at":    now,
+		"moderation_note": strings.TrimSpace(req.Note),
+	}).Error; err != nil {
+		return c.Status(400).JSON(fiber.Map{"msg": "failed to moderate review: " + err.Error()})
+	}
+
+	db.Preload("Reviewer").Preload("Reviewee").Preload("Project").First(&review, review.ID)
+
+	message := fmt.Sprintf("The dispute on review #%d was resolved: the review was ", review.ID)
+	if status == StatusRemoved {
+		message += "removed"
+	} else {
+		message += "upheld"
+	}
+	notification.Notify(db, []uint{review.ReviewerID, review.RevieweeID}, "review_moderated", "Review Dispute Resolved", message, fmt.Sprintf("/reviews/%d", review.ID))
+
+	return c.JSON(fiber.Map{"data": review})
+}
@@ -0,0 +1,25 @@
+package review
+
+import "gorm.io/gorm"
+
+// Summary averages the published reviews received by one or more users
+type Summary struct {
+	Count         int64   `json:"count"`
+	Quality       float64 `json:"quality"`
+	Communication float64 `json:"communication"`
+	Timeliness    float64 `json:"timeliness"`
+	Overall       float64 `json:"overall"`
+}
+
+// SummaryFor averages the published reviews in the given direction received by the users
+func SummaryFor(db *gorm.DB, userIDs []uint, direction string) (Summary, error) {
+	var summary Summary
+	if len(userIDs) == 0 {
+		return summary, nil
+	}
+	err := db.Model(&Review{}).
+		Select("COUNT(*) AS count, COALESCE(AVG(quality), 0) AS quality, COALESCE(AVG(communication), 0) AS communication, COALESCE(AVG(timeliness), 0) AS timeliness, COALESCE(AVG(overall), 0) AS overall").
+		Where("reviewee_id IN ? AND direction = ? AND status = ?", userIDs, direction, StatusPublished).
+		Scan(&summary).Error
+	return summary, err
+}
@@ -0,0 +1,52 @@
+package user
+
+// membershipHooks run after users join or leave a group
+var membershipHooks []func(groupID uint, joined, left []uint)
+
+// OnMembershipChanged registers work to do when users join or leave a group, for packages that cannot be
+// imported here (call this from main.go)
+func OnMembershipChanged(hook func(groupID uint, joined, left []uint)) {
+	membershipHooks = append(membershipHooks, hook)
+}
+
+// membershipChanged runs the registered membership hooks
+func membershipChanged(groupID uint, joined, left []uint) {
+	if len(joined) == 0 && len(left) == 0 {
+		return
+	}
+	for _, hook := range membershipHooks {
+		hook(groupID, joined, left)
+	}
+}
+
+// MembershipChanged runs the membership hooks for groups whose members are changed outside this package,
+// such as the groups created for applications. Call it after the change is committed.
+func MembershipChanged(groupID uint, joined, left []uint) {
+	membershipChanged(groupID, joined, left)
+}
+
+// groupMemberIDs returns the leader and member IDs of a group
+func groupMemberIDs(group Group) []uint {
+	ids := []uint{group.UserID}
+	for _, member := range group.Members {
+		if member.ID != group.UserID {
+			ids = append(ids, member.ID)
+		}
+	}
+	return ids
+}
+
+// diffIDs returns the IDs in a that are not in b
+func diffIDs(a, b []uint) []uint {
+	in := make(map[uint]bool, len(b))
+	for _, id := range b {
+		in[id] = true
+	}
+	var diff []uint
+	for _, id := range a {
+		if !in[id] {
+			diff = append(diff, id)
+		}
+	}
+	return diff
+}
@@ -469,6 +469,7 @@ func CreateGroup(c *fiber.Ctx, db *gorm.DB) error {
 
 	// Reload with relations
 	db.Preload("User").Preload("Members").First(&group, group.ID)
+	membershipChanged(group.ID, groupMemberIDs(group), nil)
 
 	// Transform to frontend format
 	transformed := transformGroupToFrontendFormat(group)
@@ -505,6 +506,7 @@ func AddToGroup(c *fiber.Ctx, db *gorm.DB) error {
 	if err := db.Model(&group).Association("Members").Append(&user); err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to add user to group" + err.Error()})
 	}
+	membershipChanged(group.ID, []uint{user.ID}, nil)
 
 	return c.SendStatus(201)
 }
@@ -534,6 +536,7 @@ func RemoveFromGroup(c *fiber.Ctx, db *gorm.DB) error {
 	if err := db.Model(&group).Association("Members").Delete(&usr); err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to remove user"})
 	}
+	membershipChanged(group.ID, nil, []uint{usr.ID})
 
 	return c.SendStatus(200)
 }
@@ -756,9 +759,14 @@ func UpdateGroup(c *fiber.Ctx, db *gorm.DB) error {
 		}
 
 		// Replace all members
+		var previous Group
+		db.Preload("Members").First(&previous, group.ID)
 		if err := db.Model(&group).Association("Members").Replace(members); err != nil {
 			return c.Status(400).JSON(fiber.Map{"msg": "failed to update members: " + err.Error()})
 		}
+		before := groupMemberIDs(previous)
+		after := groupMemberIDs(Group{UserID: group.UserID, Members: members})
+		membershipChanged(group.ID, diffIDs(after, before), diffIDs(before, after))
 	}
 
 	// Reload with relations
@@ -794,9 +802,11 @@ func DeleteGroup(c *fiber.Ctx, db *gorm.DB) error {
 		return c.Status(403).JSON(fiber.Map{"msg": "you don't have permission to delete this group"})
 	}
 
+	db.Preload("Members").First(&group, group.ID)
 	if err := db.Delete(&group).Error; err != nil {
 		return c.Status(400).JSON(fiber.Map{"msg": "failed to delete group: " + err.Error()})
 	}
+	membershipChanged(group.ID, nil, groupMemberIDs(group))
 
 	return c.JSON(fiber.Map{"msg": "group deleted successfully"})
 }